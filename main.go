@@ -1,10 +1,22 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"log"
+	"os"
+	"strings"
+
 	"github.com/gptscript-ai/cmd"
 	"github.com/gptscript-ai/openapi-cli/pkg/cli"
 )
 
 func main() {
-	cmd.Main(cli.New())
+	if err := cli.New().ExecuteContext(cmd.SetupSignalContext()); err != nil {
+		if strings.EqualFold("interrupt", err.Error()) || errors.Is(err, context.Canceled) {
+			os.Exit(1)
+		}
+		log.Print(err)
+		os.Exit(cli.ExitCode(err))
+	}
 }
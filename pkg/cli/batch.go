@@ -0,0 +1,337 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+	"github.com/tidwall/gjson"
+)
+
+type Batch struct {
+	ContinueOnError bool     `usage:"Run the remaining steps after a step fails, instead of stopping"`
+	BaseURL         string   `usage:"URL to send the requests to instead of the operations' servers" name:"base-url"`
+	Header          []string `usage:"Extra request header for every step, as 'Name: value' (can be repeated)" split:"false"`
+	IgnoreCase      bool     `usage:"Match the operation IDs case-insensitively"`
+	LenientJSON     bool     `usage:"Allow comments and trailing commas in JSON spec files"`
+	FailFast        bool     `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+	RateLimit       string   `usage:"Maximum number of requests to send per second across all steps (e.g. 5 or 0.5), including follow-up requests"`
+}
+
+func (b *Batch) Customize(cmd *cobra.Command) {
+	cmd.Long = `Run the steps in a JSON file in order, each an operation from one of the OpenAPI files,
+and print the result of each step as JSON.
+
+The steps file is an array of steps:
+
+  [{"name": "get", "operationId": "getPet", "args": {"id": "42"}},
+   {"name": "delete", "operationId": "deletePet", "args": {"id": "42"}, "when": "get.body.name"}]
+
+A step with a "when" predicate only runs if the predicate is true. The predicate is a gjson path
+into the results of the earlier steps, keyed by step name, where each result has the status,
+headers, and body of the response, with JSON bodies parsed. The step runs if the value at the path
+exists and isn't false, null, 0, "", [], or {}. The path can also be compared with a JSON value
+using ==, !=, >, >=, <, or <=, where a value that isn't valid JSON is a string, and a ! in front
+of the predicate negates it. For example:
+
+  "when": "get.body.items.0"               runs if the items array of the get step isn't empty
+  "when": "!get.body.archived"             runs unless the get step returned archived: true
+  "when": "get.status == 200"              runs if the get step succeeded with a 200
+  "when": "get.body.count >= 10"           runs if the get step returned a count of at least 10
+  "when": "get.body.tags.#(==\"old\")"     runs if the tags of the get step include "old"
+
+Steps without a name can't be referred to. By default, the batch stops at the first step that
+fails or gets an HTTP status of 400 or higher.`
+}
+
+// batchStep is a step of a batch, as it is given in the steps file.
+type batchStep struct {
+	Name        string          `json:"name"`
+	OperationID string          `json:"operationId"`
+	Args        json.RawMessage `json:"args"`
+	When        string          `json:"when"`
+}
+
+// batchResult is the outcome of a step of a batch.
+type batchResult struct {
+	Name        string `json:"name,omitempty"`
+	OperationID string `json:"operationId"`
+	Skipped     bool   `json:"skipped,omitempty"`
+	StatusCode  int    `json:"status,omitempty"`
+	// Headers and Body are what the later steps' predicates see. JSON bodies are included as JSON.
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    any               `json:"body,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
+
+func (b *Batch) Run(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough args")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to read steps file: %w", err)
+	}
+	var steps []batchStep
+	if err := json.Unmarshal(data, &steps); err != nil {
+		return fmt.Errorf("failed to parse steps file %s: %w", args[0], err)
+	}
+
+	files, err := expandFiles(args[1:], false)
+	if err != nil {
+		return err
+	}
+
+	opts := openapi.RunOptions{IgnoreCase: b.IgnoreCase, LenientJSON: b.LenientJSON, BaseURL: b.BaseURL, Warnings: os.Stderr}
+	for _, h := range b.Header {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return fmt.Errorf("invalid header %q: expected 'Name: value'", h)
+		}
+		if opts.Headers == nil {
+			opts.Headers = map[string][]string{}
+		}
+		opts.Headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	if b.RateLimit != "" {
+		rate, err := strconv.ParseFloat(b.RateLimit, 64)
+		if err != nil {
+			return fmt.Errorf("invalid rate limit %q: %w", b.RateLimit, err)
+		}
+		// The limiter is shared by the steps, so the limit holds for the whole batch.
+		if opts.RateLimiter, err = openapi.NewRateLimiter(rate); err != nil {
+			return err
+		}
+	}
+
+	var (
+		results     = []batchResult{}
+		stepResults = map[string]batchResult{}
+		failed      int
+	)
+	for i, step := range steps {
+		if step.OperationID == "" {
+			return fmt.Errorf("step %d has no operationId", i+1)
+		}
+
+		result := batchResult{Name: step.Name, OperationID: step.OperationID}
+		if run, err := evaluateWhen(step.When, stepResults); err != nil {
+			result.Error = fmt.Sprintf("failed to evaluate when predicate %q: %v", step.When, err)
+		} else if !run {
+			result.Skipped = true
+		} else if err := b.runStep(step, files, opts, &result); err != nil {
+			result.Error = err.Error()
+		}
+
+		results = append(results, result)
+		if step.Name != "" {
+			stepResults[step.Name] = result
+		}
+		if result.Error != "" || result.StatusCode >= 400 {
+			failed++
+			if !b.ContinueOnError {
+				break
+			}
+		}
+	}
+
+	output, err := json.MarshalIndent(results, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal batch results: %w", err)
+	}
+	fmt.Println(string(output))
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d steps failed", failed, len(steps))
+	}
+	return nil
+}
+
+// runStep runs the step's operation from the first file that has it and records the response in the result.
+func (b *Batch) runStep(step batchStep, files []string, opts openapi.RunOptions, result *batchResult) error {
+	input := string(step.Args)
+	if input == "" || input == "null" {
+		input = "{}"
+	}
+
+	search := fileSearch{failFast: b.FailFast}
+	for _, file := range files {
+		resp, found, err := openapi.RunResponse(step.OperationID, file, input, opts)
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if !found {
+			continue
+		}
+
+		result.StatusCode = resp.StatusCode
+		result.Headers = map[string]string{}
+		for name := range resp.Header {
+			result.Headers[name] = resp.Header.Get(name)
+		}
+		if gjson.Valid(resp.Body) {
+			result.Body = json.RawMessage(resp.Body)
+		} else if resp.Body != "" {
+			result.Body = resp.Body
+		}
+		return nil
+	}
+
+	return search.notFound(step.OperationID, files)
+}
+
+// evaluateWhen returns whether a step with the predicate should run, given the results of the earlier named steps.
+// An empty predicate is always true.
+func evaluateWhen(when string, stepResults map[string]batchResult) (bool, error) {
+	when = strings.TrimSpace(when)
+	if when == "" {
+		return true, nil
+	}
+
+	negate := strings.HasPrefix(when, "!")
+	when = strings.TrimSpace(strings.TrimPrefix(when, "!"))
+	path, operator, operand := splitComparison(when)
+	if path == "" {
+		return false, fmt.Errorf("empty path")
+	}
+
+	data, err := json.Marshal(stepResults)
+	if err != nil {
+		return false, fmt.Errorf("failed to marshal step results: %w", err)
+	}
+	value := gjson.GetBytes(data, path)
+
+	var result bool
+	if operator == "" {
+		result = truthy(value)
+	} else {
+		// The operand is a JSON value, or a string if it isn't valid JSON.
+		other := gjson.Parse(operand)
+		if !gjson.Valid(operand) {
+			other = gjson.Result{Type: gjson.String, Str: operand}
+		}
+		result, err = compareValues(value, operator, other)
+		if err != nil {
+			return false, err
+		}
+	}
+	return result != negate, nil
+}
+
+// comparisonOperators are the operators a when predicate can compare a value with, longest first so that
+// >= isn't read as >.
+var comparisonOperators = []string{"==", "!=", ">=", "<=", ">", "<"}
+
+// splitComparison splits a when predicate into the path, and the operator and operand of its comparison, if it has one.
+// Operators inside parentheses or quotes are part of a gjson query in the path and aren't split on.
+func splitComparison(when string) (path, operator, operand string) {
+	var (
+		depth  int
+		quoted bool
+	)
+	for i := 0; i < len(when); i++ {
+		switch c := when[i]; {
+		case c == '\\' && quoted:
+			i++
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case depth == 0:
+			for _, op := range comparisonOperators {
+				if strings.HasPrefix(when[i:], op) {
+					return strings.TrimSpace(when[:i]), op, strings.TrimSpace(when[i+len(op):])
+				}
+			}
+		}
+	}
+	return when, "", ""
+}
+
+// compareValues compares a value from the step results with the operand of a when predicate. Values that don't
+// exist are null. Numbers and strings can be ordered, and other values can only be checked for equality.
+func compareValues(value gjson.Result, operator string, operand gjson.Result) (bool, error) {
+	var cmp int
+	switch {
+	case value.Type == gjson.Number && operand.Type == gjson.Number:
+		cmp = compareOrdered(value.Num, operand.Num)
+	case value.Type == gjson.String && operand.Type == gjson.String:
+		cmp = compareOrdered(value.Str, operand.Str)
+	case operator == "==" || operator == "!=":
+		equal := value.Type == operand.Type && (value.Type != gjson.JSON || value.Raw == operand.Raw)
+		return equal == (operator == "=="), nil
+	default:
+		return false, fmt.Errorf("can't compare %s with %s using %s", valueType(value), valueType(operand), operator)
+	}
+
+	switch operator {
+	case "==":
+		return cmp == 0, nil
+	case "!=":
+		return cmp != 0, nil
+	case ">=":
+		return cmp >= 0, nil
+	case "<=":
+		return cmp <= 0, nil
+	case ">":
+		return cmp > 0, nil
+	default:
+		return cmp < 0, nil
+	}
+}
+
+func compareOrdered[T float64 | string](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// valueType names the type of a value for an error message.
+func valueType(value gjson.Result) string {
+	switch {
+	case !value.Exists() || value.Type == gjson.Null:
+		return "null"
+	case value.IsArray():
+		return "an array"
+	case value.IsObject():
+		return "an object"
+	case value.Type == gjson.True || value.Type == gjson.False:
+		return "a boolean"
+	}
+	return "a " + strings.ToLower(value.Type.String())
+}
+
+// truthy returns whether a value counts as true for a when predicate: it exists and isn't false, null, 0, "", [], or {}.
+func truthy(value gjson.Result) bool {
+	switch {
+	case !value.Exists():
+		return false
+	case value.IsArray():
+		return len(value.Array()) > 0
+	case value.IsObject():
+		return len(value.Map()) > 0
+	}
+	switch value.Type {
+	case gjson.Null, gjson.False:
+		return false
+	case gjson.Number:
+		return value.Num != 0
+	case gjson.String:
+		return value.Str != ""
+	}
+	return true
+}
@@ -0,0 +1,110 @@
+package cli
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestEvaluateWhen(t *testing.T) {
+	stepResults := map[string]batchResult{
+		"get": {
+			Name:       "get",
+			StatusCode: 200,
+			Body:       json.RawMessage(`{"name": "Rex", "items": [1], "empty": [], "archived": true, "count": 12, "tags": ["old", "a>b"]}`),
+		},
+		"list": {
+			Name:       "list",
+			StatusCode: 200,
+			Body:       json.RawMessage(`{"items": [], "counts": [3, 15]}`),
+		},
+	}
+
+	tests := []struct {
+		when string
+		want bool
+		err  string
+	}{
+		{when: "", want: true},
+
+		// The examples in the help.
+		{when: "get.body.items.0", want: true},
+		{when: "list.body.items.0", want: false},
+		{when: "!get.body.archived", want: false},
+		{when: "get.status == 200", want: true},
+		{when: "get.body.count >= 10", want: true},
+		{when: `get.body.tags.#(=="old")`, want: true},
+		{when: `get.body.tags.#(=="new")`, want: false},
+
+		// Truthiness.
+		{when: "get.body.empty", want: false},
+		{when: "get.body.missing", want: false},
+		{when: "!get.body.missing", want: true},
+		{when: "missing.body", want: false},
+
+		// Comparisons.
+		{when: "get.status != 200", want: false},
+		{when: "get.body.count < 12", want: false},
+		{when: "get.body.count <= 12", want: true},
+		{when: "get.body.count > 3", want: true},
+		{when: "get.body.name == Rex", want: true},
+		{when: `get.body.name == "Rex"`, want: true},
+		{when: "get.body.name >= Max", want: true},
+		{when: "get.body.missing == null", want: true},
+		{when: "get.body.items == [1]", want: true},
+		{when: "!get.status == 200", want: false},
+
+		// Operators inside a query or a quoted string aren't split on.
+		{when: `get.body.tags.#(!="old") == "a>b"`, want: true},
+		{when: `list.body.counts.#(>=10) == 15`, want: true},
+		{when: `list.body.counts.#(>=10)`, want: true},
+		{when: `get.body.tags.#(=="a>b")`, want: true},
+		{when: `get.body.tags.#(=="a\"b") == null`, want: true},
+
+		// Errors.
+		{when: "!", err: "empty path"},
+		{when: "== 200", err: "empty path"},
+		{when: "get.body.name > 3", err: "can't compare a string with a number using >"},
+		{when: "get.body.items >= 1", err: "can't compare an array with a number using >="},
+		{when: "get.body.missing < 1", err: "can't compare null with a number using <"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			got, err := evaluateWhen(tt.when, stepResults)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitComparison(t *testing.T) {
+	tests := []struct {
+		when, path, operator, operand string
+	}{
+		{"get.status", "get.status", "", ""},
+		{"get.status == 200", "get.status", "==", "200"},
+		{"get.status>=200", "get.status", ">=", "200"},
+		{"get.status <= 200", "get.status", "<=", "200"},
+		{`get.body.tags.#(!="old")`, `get.body.tags.#(!="old")`, "", ""},
+		{`get.body.tags.#(>="b") != "c"`, `get.body.tags.#(>="b")`, "!=", `"c"`},
+		{`get.body.tags.#(=="x)y") == 1`, `get.body.tags.#(=="x)y")`, "==", "1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.when, func(t *testing.T) {
+			path, operator, operand := splitComparison(tt.when)
+			if path != tt.path || operator != tt.operator || operand != tt.operand {
+				t.Errorf("got %q %q %q, want %q %q %q", path, operator, operand, tt.path, tt.operator, tt.operand)
+			}
+		})
+	}
+}
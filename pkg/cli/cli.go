@@ -11,6 +11,7 @@ type OpenAPICLI struct {
 }
 
 func (o *OpenAPICLI) Customize(cmd *cobra.Command) {
+	cmd.Long = exitCodesHelp
 }
 
 func (o *OpenAPICLI) Run(*cobra.Command, []string) error {
@@ -19,7 +20,7 @@ func (o *OpenAPICLI) Run(*cobra.Command, []string) error {
 }
 
 func New() *cobra.Command {
-	return cmd.Command(&OpenAPICLI{}, &List{}, &GetSchema{}, &Run{})
+	return cmd.Command(&OpenAPICLI{}, &List{}, &GetSchema{}, &Run{}, &Examples{}, &Serve{}, &Sample{}, &GenTypes{}, &GenTS{}, &Dump{}, &Describe{}, &Export{}, &Validate{}, &Diff{}, &Batch{})
 }
 
 func printUsage() {
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Describe struct {
+	IgnoreCase  bool     `usage:"Match the operation ID case-insensitively"`
+	ServerVar   []string `usage:"Value of a variable in the operation's server URL, as name=value (can be repeated)" split:"false"`
+	LenientJSON bool     `usage:"Allow comments and trailing commas in JSON spec files"`
+	FailFast    bool     `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+	Extension   []string `usage:"x- extension of the operation to include, such as x-ratelimit, or * for all of them (can be repeated)" split:"false"`
+}
+
+func (d *Describe) Customize(cmd *cobra.Command) {
+	cmd.Long = `Describe an operation: its method, path, and server, its parameters and request body,
+and the security schemes that can authenticate it.
+
+The variables of the server URL are listed with their allowed values and the value that is used,
+which can be chosen with --server-var, the same way as with run.`
+}
+
+func (d *Describe) Run(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough args")
+	}
+
+	operationID := args[0]
+	files := args[1:]
+
+	serverVariables, err := parseServerVariables(d.ServerVar)
+	if err != nil {
+		return err
+	}
+	opts := openapi.SchemaOptions{
+		IgnoreCase:      d.IgnoreCase,
+		ServerVariables: serverVariables,
+		LenientJSON:     d.LenientJSON,
+		Extensions:      d.Extension,
+	}
+
+	search := fileSearch{failFast: d.FailFast}
+	for _, file := range files {
+		description, found, err := openapi.Describe(operationID, file, opts)
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to describe operation %s in file %s: %w", operationID, file, err)
+		}
+		if !found {
+			continue
+		}
+
+		output, err := json.MarshalIndent(description, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal operation description: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	return search.notFound(operationID, files)
+}
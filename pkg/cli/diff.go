@@ -0,0 +1,48 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Diff struct {
+	FailOnBreaking bool `usage:"Fail if there are breaking changes"`
+	LenientJSON    bool `usage:"Allow comments and trailing commas in JSON spec files"`
+}
+
+func (d *Diff) Customize(cmd *cobra.Command) {
+	cmd.Long = `Compare two versions of an OpenAPI file and print the differences between their operations as JSON.
+
+Operations are matched by their IDs. The output lists the operations that were added and removed, and for
+the others, the changes to their method, path, and arguments. The arguments are compared the way get-schema
+builds them, so parameters and the request body are compared at every level.
+
+Changes that can break existing callers are marked as breaking: removed operations, removed arguments,
+arguments that became required, changed types, and removed enum values. With --fail-on-breaking, the
+command fails if there are any, so that it can be used to check compatibility in CI.`
+}
+
+func (d *Diff) Run(_ *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected an old and a new file")
+	}
+
+	diff, err := openapi.Diff(args[0], args[1], d.LenientJSON)
+	if err != nil {
+		return fmt.Errorf("failed to compare files %s and %s: %w", args[0], args[1], err)
+	}
+
+	output, err := json.MarshalIndent(diff, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal diff: %w", err)
+	}
+	fmt.Println(string(output))
+
+	if d.FailOnBreaking && diff.Breaking > 0 {
+		return fmt.Errorf("found %d breaking change(s)", diff.Breaking)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Dump struct {
+	KeepRefs    bool `usage:"Leave $ref values in place instead of replacing them with what they point to"`
+	YAML        bool `usage:"Output YAML instead of JSON" name:"yaml"`
+	LenientJSON bool `usage:"Allow comments and trailing commas in JSON spec files"`
+}
+
+func (d *Dump) Customize(cmd *cobra.Command) {
+	cmd.Long = `Print an OpenAPI document the way the CLI sees it after loading it.
+
+Internal and external references are resolved and replaced with what they point to, so the
+output shows the schemas that the other commands work with. References that form a cycle are
+left as $ref.`
+}
+
+func (d *Dump) Run(_ *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one file")
+	}
+
+	out, err := openapi.Dump(args[0], openapi.DumpOptions{
+		KeepRefs:    d.KeepRefs,
+		YAML:        d.YAML,
+		LenientJSON: d.LenientJSON,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Print(out)
+	return nil
+}
@@ -0,0 +1,109 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+)
+
+// Exit codes used by the CLI, so that scripts can tell different kinds of failures apart.
+const (
+	// ExitCodeError is used for any failure that doesn't have a more specific exit code.
+	ExitCodeError = 1
+	// ExitCodeNotFound is used when the operation isn't found in any of the files.
+	ExitCodeNotFound = 2
+	// ExitCodeInvalidArguments is used when the arguments don't match the operation's schema.
+	ExitCodeInvalidArguments = 3
+	// ExitCodeRequestFailed is used when the request can't be sent or the response can't be read.
+	ExitCodeRequestFailed = 4
+	// ExitCodeHTTPError is used when the response has an HTTP status code of 400 or higher.
+	ExitCodeHTTPError = 5
+	// ExitCodeLoadFailed is used when the operation isn't found and some of the files failed to load,
+	// so the operation may be in one of them.
+	ExitCodeLoadFailed = 6
+)
+
+const exitCodesHelp = `Exit codes:
+  1  any other failure
+  2  operation not found in any file
+  3  arguments don't match the operation's schema
+  4  request failed (network error)
+  5  response has an HTTP status of 400 or higher
+  6  operation not found, and some files failed to load`
+
+// notFoundError is returned when an operation isn't found in any of the files.
+type notFoundError struct {
+	operationID string
+	// suggestions are similar operation IDs from the files.
+	suggestions []string
+	// loadErrors are the errors of the files that failed to load and were skipped.
+	loadErrors []error
+}
+
+func (e *notFoundError) Error() string {
+	var msg string
+	if len(e.suggestions) > 0 {
+		msg = fmt.Sprintf("operation %s not found in any file (did you mean %s?)", e.operationID, strings.Join(e.suggestions, ", "))
+	} else {
+		msg = fmt.Sprintf("operation %s not found in any file", e.operationID)
+	}
+	return msg + describeLoadErrors(e.loadErrors)
+}
+
+// describeLoadErrors returns the suffix of a not found message that lists the files that failed to load, if any.
+func describeLoadErrors(loadErrors []error) string {
+	if len(loadErrors) == 0 {
+		return ""
+	}
+	msg := fmt.Sprintf("; %d file(s) failed to load:", len(loadErrors))
+	for _, err := range loadErrors {
+		msg += "\n  " + err.Error()
+	}
+	return msg
+}
+
+// httpStatusError is returned when the response to an operation has an error status code.
+type httpStatusError struct {
+	statusCode int
+	// retryAfter is how long the server asked to wait before retrying, or zero.
+	retryAfter time.Duration
+}
+
+func (e *httpStatusError) Error() string {
+	if e.retryAfter > 0 {
+		return fmt.Sprintf("operation returned HTTP status %d %s (retry after %s)", e.statusCode, http.StatusText(e.statusCode), e.retryAfter.Round(time.Second))
+	}
+	return fmt.Sprintf("operation returned HTTP status %d %s", e.statusCode, http.StatusText(e.statusCode))
+}
+
+// ExitCode returns the exit code to use for an error returned by the CLI.
+func ExitCode(err error) int {
+	var (
+		notFoundErr   *notFoundError
+		validationErr *openapi.ValidationError
+		requestErr    *openapi.RequestError
+		statusErr     *httpStatusError
+	)
+
+	switch {
+	case err == nil:
+		return 0
+	case errors.As(err, &notFoundErr):
+		if len(notFoundErr.loadErrors) > 0 {
+			return ExitCodeLoadFailed
+		}
+		return ExitCodeNotFound
+	case errors.As(err, &validationErr):
+		return ExitCodeInvalidArguments
+	case errors.As(err, &requestErr):
+		return ExitCodeRequestFailed
+	case errors.As(err, &statusErr):
+		return ExitCodeHTTPError
+	default:
+		return ExitCodeError
+	}
+}
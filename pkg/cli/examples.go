@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Examples struct {
+	FailFast bool `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+}
+
+func (e *Examples) Run(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough args")
+	}
+
+	operationID := args[0]
+	files := args[1:]
+
+	search := fileSearch{failFast: e.FailFast}
+	for _, file := range files {
+		_, info, found, err := openapi.GetSchema(operationID, file, openapi.SchemaOptions{})
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to get examples for operation %s in file %s: %w", operationID, file, err)
+		}
+		if !found {
+			continue
+		}
+
+		examples := info.Examples
+		if examples == nil {
+			examples = map[string]openapi.Example{}
+		}
+
+		examplesJSON, err := json.MarshalIndent(examples, "", "    ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal examples: %w", err)
+		}
+
+		fmt.Println(string(examplesJSON))
+		return nil
+	}
+
+	return search.notFound(operationID, files)
+}
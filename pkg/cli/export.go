@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Export struct {
+	LenientJSON bool `usage:"Allow comments and trailing commas in JSON spec files"`
+}
+
+func (e *Export) Customize(cmd *cobra.Command) {
+	cmd.Long = `Convert an OpenAPI file into another format. The args are the format and the file.
+
+Formats:
+  postman  a Postman v2.1 collection, with a folder for each tag and a request for each operation`
+}
+
+func (e *Export) Run(_ *cobra.Command, args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("expected a format and a file")
+	}
+
+	format, file := args[0], args[1]
+	var (
+		output string
+		err    error
+	)
+	switch format {
+	case "postman":
+		output, err = openapi.ExportPostman(file, e.LenientJSON)
+	default:
+		return fmt.Errorf("unsupported export format %q (supported formats: postman)", format)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(output)
+	return nil
+}
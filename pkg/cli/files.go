@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+)
+
+// specExtensions are the extensions of the files that are treated as OpenAPI specs when a directory is given.
+var specExtensions = map[string]bool{
+	".json": true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// expandFiles replaces each directory in the args with the spec files in it, sorted by name,
+// and keeps the other args as they are. Subdirectories are only searched when recursive is set.
+func expandFiles(args []string, recursive bool) ([]string, error) {
+	var files []string
+	for _, arg := range args {
+		info, err := os.Stat(arg)
+		if err != nil || !info.IsDir() {
+			// Let the loader report files that don't exist.
+			files = append(files, arg)
+			continue
+		}
+
+		err = filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if path != arg && !recursive {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if specExtensions[strings.ToLower(filepath.Ext(path))] {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read directory %s: %w", arg, err)
+		}
+	}
+	return files, nil
+}
+
+// fileSearch tracks the files that fail to load while an operation is searched for in several files,
+// so that a broken file doesn't hide the operation in a later one.
+type fileSearch struct {
+	// failFast stops the search at the first file that fails to load.
+	failFast   bool
+	loadErrors []error
+}
+
+// skip returns whether the search should go on to the next file after the error,
+// which it does for load errors unless failFast is set. The skipped errors are kept.
+func (s *fileSearch) skip(err error) bool {
+	var loadErr *openapi.LoadError
+	if s.failFast || !errors.As(err, &loadErr) {
+		return false
+	}
+	s.loadErrors = append(s.loadErrors, loadErr)
+	return true
+}
+
+// notFound returns the error for an operation that wasn't found in any of the files,
+// which includes the errors of the files that failed to load, since the operation may be in them.
+func (s *fileSearch) notFound(operationID string, files []string) error {
+	err := newNotFoundError(operationID, files)
+	err.loadErrors = s.loadErrors
+	return err
+}
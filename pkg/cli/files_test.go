@@ -0,0 +1,68 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestRunSkipsFilesThatFailToLoad(t *testing.T) {
+	var requests int
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		requests++
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{}`)), Request: r}, nil
+	})
+	defer func() { http.DefaultClient.Transport = transport }()
+
+	tests := []struct {
+		name        string
+		args        []string
+		requests    int
+		exitCode    int
+		errContains string
+	}{
+		{
+			name:     "broken file before the operation's file",
+			args:     []string{"getPet", `{"id": "1"}`, "testdata/broken.json", "testdata/pets.yaml"},
+			requests: 1,
+		},
+		{
+			name:        "operation not found after a broken file",
+			args:        []string{"deletePet", `{"id": "1"}`, "testdata/broken.json", "testdata/pets.yaml"},
+			exitCode:    ExitCodeLoadFailed,
+			errContains: "1 file(s) failed to load:\n  failed to load OpenAPI file testdata/broken.json",
+		},
+		{
+			name:        "operation not found",
+			args:        []string{"deletePet", `{"id": "1"}`, "testdata/pets.yaml"},
+			exitCode:    ExitCodeNotFound,
+			errContains: "operation deletePet not found in any file",
+		},
+		{
+			name:        "fail fast",
+			args:        []string{"--fail-fast", "getPet", `{"id": "1"}`, "testdata/broken.json", "testdata/pets.yaml"},
+			exitCode:    ExitCodeError,
+			errContains: "failed to load OpenAPI file testdata/broken.json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests = 0
+			cmd := New()
+			cmd.SetArgs(append([]string{"run", "--no-env-auth"}, tt.args...))
+			err := cmd.Execute()
+
+			if got := ExitCode(err); got != tt.exitCode {
+				t.Errorf("got exit code %d for error %v, want %d", got, err, tt.exitCode)
+			}
+			if tt.errContains != "" && (err == nil || !strings.Contains(err.Error(), tt.errContains)) {
+				t.Errorf("got error %v, want it to contain %q", err, tt.errContains)
+			}
+			if requests != tt.requests {
+				t.Errorf("got %d requests, want %d", requests, tt.requests)
+			}
+		})
+	}
+}
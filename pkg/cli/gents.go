@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type GenTS struct {
+	Response    bool `usage:"Also generate a type for the success response body"`
+	LenientJSON bool `usage:"Allow comments and trailing commas in JSON spec files"`
+	FailFast    bool `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+}
+
+func (g *GenTS) Customize(cmd *cobra.Command) {
+	cmd.Long = `Generate TypeScript types for the arguments of an operation, and optionally its success response body.
+
+Objects become interfaces, with optional properties for the ones that aren't required, and enums
+become unions of their values. An object of the arguments type can be passed to run as JSON.`
+}
+
+func (g *GenTS) Run(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough args")
+	}
+
+	operationID := args[0]
+	files := args[1:]
+
+	search := fileSearch{failFast: g.FailFast}
+	for _, file := range files {
+		src, found, err := openapi.GenerateTypeScript(operationID, file, g.Response, g.LenientJSON)
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to generate TypeScript types for operation %s in file %s: %w", operationID, file, err)
+		}
+		if !found {
+			continue
+		}
+		fmt.Print(src)
+		return nil
+	}
+
+	return search.notFound(operationID, files)
+}
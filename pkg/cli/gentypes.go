@@ -0,0 +1,47 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type GenTypes struct {
+	Package     string `usage:"Package name for the generated code" default:"types"`
+	LenientJSON bool   `usage:"Allow comments and trailing commas in JSON spec files"`
+	FailFast    bool   `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+}
+
+func (g *GenTypes) Customize(cmd *cobra.Command) {
+	cmd.Long = `Generate Go types for the arguments and the success response body of an operation.
+
+Objects become structs, arrays become slices, and string enums become a string type
+with a constant for each value. The arguments type can be marshaled to JSON and passed to run.`
+}
+
+func (g *GenTypes) Run(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough args")
+	}
+
+	operationID := args[0]
+	files := args[1:]
+
+	search := fileSearch{failFast: g.FailFast}
+	for _, file := range files {
+		src, found, err := openapi.GenerateTypes(operationID, file, g.Package, g.LenientJSON)
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to generate types for operation %s in file %s: %w", operationID, file, err)
+		}
+		if !found {
+			continue
+		}
+		fmt.Print(src)
+		return nil
+	}
+
+	return search.notFound(operationID, files)
+}
@@ -1,33 +1,131 @@
 package cli
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
 	"github.com/spf13/cobra"
 )
 
-type GetSchema struct{}
+type GetSchema struct {
+	KeepRefs     bool     `usage:"Keep references to component schemas instead of inlining them"`
+	MergeAllOf   bool     `usage:"Merge allOf subschemas into a single schema"`
+	BodyIsRoot   bool     `usage:"Use the request body schema as the root schema for operations with a body and no parameters"`
+	IgnoreCase   bool     `usage:"Match the operation ID case-insensitively"`
+	RequiredOnly bool     `usage:"Only include required parameters and body properties"`
+	Parameters   bool     `usage:"Output each parameter with its location and serialization, and the request body schema, instead of one merged schema"`
+	All          bool     `usage:"Output the schemas of all the operations, as a JSON object keyed by operation ID; the args are then only files"`
+	Operations   []string `usage:"Output the schemas of these operations, as a JSON object keyed by operation ID (can be repeated or comma-separated); the args are then only files"`
+	LenientJSON  bool     `usage:"Allow comments and trailing commas in JSON spec files"`
+	Recursive    bool     `usage:"Also search the subdirectories of directories given as files"`
+	FailFast     bool     `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+}
 
 func (g *GetSchema) Run(_ *cobra.Command, args []string) error {
+	if g.All || len(g.Operations) > 0 {
+		return g.runBatch(args)
+	}
 	if len(args) < 2 {
 		return fmt.Errorf("not enough args")
 	}
 
 	operationID := args[0]
-	files := args[1:]
+	files, err := expandFiles(args[1:], g.Recursive)
+	if err != nil {
+		return err
+	}
 
+	search := fileSearch{failFast: g.FailFast}
 	for _, file := range files {
-		schema, _, found, err := openapi.GetSchema(operationID, file)
-		if err != nil {
+		opts := openapi.SchemaOptions{
+			KeepRefs:     g.KeepRefs,
+			MergeAllOf:   g.MergeAllOf,
+			BodyIsRoot:   g.BodyIsRoot,
+			IgnoreCase:   g.IgnoreCase,
+			RequiredOnly: g.RequiredOnly,
+			LenientJSON:  g.LenientJSON,
+		}
+
+		var (
+			schema string
+			opInfo openapi.OperationInfo
+			found  bool
+			err    error
+		)
+		if g.Parameters {
+			schema, found, err = openapi.GetParameterSchemas(operationID, file, opts)
+		} else {
+			schema, opInfo, found, err = openapi.GetSchema(operationID, file, opts)
+		}
+		if search.skip(err) {
+			continue
+		} else if err != nil {
 			return fmt.Errorf("failed to get schema for operation %s in file %s: %w", operationID, file, err)
 		}
 		if !found {
 			continue
 		}
+		for _, name := range opInfo.ConflictingParameters {
+			_, _ = fmt.Fprintf(os.Stderr, "warning: parameter %s is defined at the path and operation level with different schemas; using the operation's definition\n", name)
+		}
 		fmt.Println(schema)
 		return nil
 	}
 
-	return fmt.Errorf("operation %s not found in any file", operationID)
+	return search.notFound(operationID, files)
+}
+
+// runBatch prints the schemas of several operations, or of all the operations, from the files.
+// When an operation is in several files, the first file wins.
+func (g *GetSchema) runBatch(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("not enough args")
+	}
+	if g.All && len(g.Operations) > 0 {
+		return fmt.Errorf("--all and --operations cannot be used together")
+	}
+	if g.Parameters || g.BodyIsRoot {
+		return fmt.Errorf("--parameters and --body-is-root cannot be used with --all or --operations")
+	}
+
+	opts := openapi.SchemaOptions{
+		KeepRefs:     g.KeepRefs,
+		MergeAllOf:   g.MergeAllOf,
+		IgnoreCase:   g.IgnoreCase,
+		RequiredOnly: g.RequiredOnly,
+		LenientJSON:  g.LenientJSON,
+	}
+
+	files, err := expandFiles(args, g.Recursive)
+	if err != nil {
+		return err
+	}
+
+	result := map[string]json.RawMessage{}
+	for _, file := range files {
+		schemas, err := openapi.GetSchemas(g.Operations, file, opts)
+		if err != nil {
+			return fmt.Errorf("failed to get schemas in file %s: %w", file, err)
+		}
+		for operationID, schema := range schemas {
+			if _, ok := result[operationID]; !ok {
+				result[operationID] = schema
+			}
+		}
+	}
+
+	for _, operationID := range g.Operations {
+		if _, ok := result[operationID]; !ok && !g.IgnoreCase {
+			return newNotFoundError(operationID, files)
+		}
+	}
+
+	output, err := json.MarshalIndent(result, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal schemas: %w", err)
+	}
+	fmt.Println(string(output))
+	return nil
 }
@@ -3,25 +3,42 @@ package cli
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
 	"github.com/spf13/cobra"
 )
 
-type List struct{}
+type List struct {
+	GroupByTag  bool     `usage:"Group the operations by tag; operations without tags are under 'default'"`
+	Callbacks   bool     `usage:"Include the callbacks declared by each operation"`
+	LenientJSON bool     `usage:"Allow comments and trailing commas in JSON spec files"`
+	Recursive   bool     `usage:"Also search the subdirectories of directories given as files"`
+	Extension   []string `usage:"x- extension to include for each operation, such as x-ratelimit, or * for all of them (can be repeated)" split:"false"`
+}
 
 func (l *List) Run(_ *cobra.Command, args []string) error {
 	if len(args) == 0 {
 		return fmt.Errorf("no files provided")
 	}
 
-	for _, file := range args {
-		operationList, err := openapi.List(file)
+	files, err := expandFiles(args, l.Recursive)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		operationList, err := openapi.List(file, openapi.ListOptions{Callbacks: l.Callbacks, LenientJSON: l.LenientJSON, Extensions: l.Extension, Warnings: os.Stderr})
 		if err != nil {
 			return fmt.Errorf("failed to list operations for file %s: %w", file, err)
 		}
 
-		operationListJSON, err := json.MarshalIndent(operationList, "", "    ")
+		var output any = operationList
+		if l.GroupByTag {
+			output = operationList.GroupByTag()
+		}
+
+		operationListJSON, err := json.MarshalIndent(output, "", "    ")
 		if err != nil {
 			return fmt.Errorf("failed to marshal operation list: %w", err)
 		}
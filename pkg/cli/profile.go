@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// profilesEnvVar is the environment variable with the path of the profiles file, when --profile-file isn't given.
+const profilesEnvVar = "OPENAPI_CLI_PROFILES"
+
+// profile is a named set of settings for one environment, such as staging or production.
+// The settings are applied before the flags, so flags take precedence over them.
+type profile struct {
+	// Headers are extra request headers, like --header.
+	Headers map[string]string `json:"headers"`
+	// ServerVars are values of variables in the server URL, like --server-var.
+	ServerVars map[string]string `json:"serverVars"`
+	// Query holds extra query parameters, like --query.
+	Query map[string]string `json:"query"`
+	// Env sets environment variables, such as OPENAPI_BEARER or the AWS_* credentials.
+	Env map[string]string `json:"env"`
+	// BaseURL replaces the operation's server, like --base-url.
+	BaseURL string `json:"baseUrl"`
+}
+
+// defaultProfilesFile returns the path of the profiles file: $OPENAPI_CLI_PROFILES, or profiles.json
+// in the openapi-cli directory of the user's config directory.
+func defaultProfilesFile() (string, error) {
+	if file := os.Getenv(profilesEnvVar); file != "" {
+		return file, nil
+	}
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to find profiles file: %w", err)
+	}
+	return filepath.Join(dir, "openapi-cli", "profiles.json"), nil
+}
+
+// readProfile reads the named profile from a profiles file, which maps profile names to their settings.
+func readProfile(file, name string) (profile, error) {
+	if file == "" {
+		var err error
+		if file, err = defaultProfilesFile(); err != nil {
+			return profile{}, err
+		}
+	}
+
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return profile{}, fmt.Errorf("failed to read profiles file: %w", err)
+	}
+
+	var profiles map[string]profile
+	if err := json.Unmarshal(data, &profiles); err != nil {
+		return profile{}, fmt.Errorf("failed to parse profiles file %s: %w", file, err)
+	}
+
+	p, ok := profiles[name]
+	if !ok {
+		names := make([]string, 0, len(profiles))
+		for n := range profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		return profile{}, fmt.Errorf("profile %s not found in %s (available profiles: %s)", name, file, strings.Join(names, ", "))
+	}
+	return p, nil
+}
+
+// applyEnv sets the profile's environment variables.
+func (p profile) applyEnv() error {
+	for name, value := range p.Env {
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("failed to set environment variable %s: %w", name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc is an http.RoundTripper that calls the function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestRunQueryFlagReplacesProfileQuery(t *testing.T) {
+	var query string
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		query = r.URL.RawQuery
+		return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader(`{}`)), Request: r}, nil
+	})
+	defer func() { http.DefaultClient.Transport = transport }()
+
+	profiles := filepath.Join(t.TempDir(), "profiles.json")
+	if err := os.WriteFile(profiles, []byte(`{"dev": {"query": {"debug": "1", "trace": "1"}}}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := New()
+	cmd.SetArgs([]string{"run", "--no-env-auth", "--profile-file", profiles, "--profile", "dev",
+		"--query", "debug=0", "getPet", `{"id": "1"}`, "testdata/pets.yaml"})
+	if err := cmd.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if query != "debug=0&trace=1" {
+		t.Errorf("got query %q, want debug=0&trace=1", query)
+	}
+}
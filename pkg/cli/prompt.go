@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// isTerminal returns whether the file is attached to a terminal.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// promptForMissingArgs asks the user for the value of each required argument in the schema
+// that is not present in the input, and returns the input with those values added.
+func promptForMissingArgs(schemaJSON, input string, in io.Reader, out io.Writer) (string, error) {
+	if input == "" {
+		input = "{}"
+	}
+	if !gjson.Parse(input).IsObject() {
+		// Only object arguments have named properties to prompt for, such as when an array is used as the body.
+		return input, nil
+	}
+
+	args := map[string]any{}
+	decoder := json.NewDecoder(strings.NewReader(input))
+	decoder.UseNumber()
+	if err := decoder.Decode(&args); err != nil {
+		return "", fmt.Errorf("failed to parse input: %w", err)
+	}
+
+	reader := bufio.NewReader(in)
+	for _, required := range gjson.Get(schemaJSON, "required").Array() {
+		name := required.String()
+		if _, ok := args[name]; ok {
+			continue
+		}
+
+		property := gjson.Get(schemaJSON, "properties."+gjson.Escape(name))
+		propertyType := property.Get("type").String()
+
+		if description := property.Get("description").String(); description != "" {
+			_, _ = fmt.Fprintf(out, "%s\n", description)
+		}
+		var options []string
+		for _, e := range property.Get("enum").Array() {
+			options = append(options, e.String())
+		}
+
+		for {
+			_, _ = fmt.Fprintf(out, "%s (%s)", name, propertyType)
+			if len(options) > 0 {
+				_, _ = fmt.Fprintf(out, " [%s]", strings.Join(options, ", "))
+			}
+			_, _ = fmt.Fprint(out, ": ")
+
+			line, err := reader.ReadString('\n')
+			if err != nil && (err != io.EOF || line == "") {
+				return "", fmt.Errorf("failed to read value for %s: %w", name, err)
+			}
+
+			value, err := parsePromptValue(strings.TrimSpace(line), propertyType)
+			if err != nil {
+				_, _ = fmt.Fprintf(out, "invalid value for %s: %v\n", name, err)
+				continue
+			}
+
+			args[name] = value
+			break
+		}
+	}
+
+	result, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal input: %w", err)
+	}
+	return string(result), nil
+}
+
+// parsePromptValue converts a value typed by the user into the JSON type expected by the schema.
+// Strings are taken literally, and everything else is parsed as JSON.
+func parsePromptValue(value, schemaType string) (any, error) {
+	if schemaType == "string" || schemaType == "" {
+		return value, nil
+	}
+
+	if !json.Valid([]byte(value)) {
+		return nil, fmt.Errorf("expected a JSON %s", schemaType)
+	}
+	return json.RawMessage(value), nil
+}
@@ -1,36 +1,951 @@
 package cli
 
 import (
+	"crypto/tls"
+	"encoding/json"
 	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
 	"github.com/spf13/cobra"
 )
 
 type Run struct {
-	DefaultHost string `json:"defaultHost"`
+	DefaultHost        string   `json:"defaultHost"`
+	InputFile          string   `usage:"Read the arguments from this JSON file instead of the command line"`
+	ArgsFromStdin      bool     `usage:"Read the arguments as name=value lines from stdin instead of the command line (see above)"`
+	BodyIsRoot         bool     `usage:"Use the whole arguments object as the request body for operations with a body and no parameters"`
+	OperationFromURL   string   `usage:"Find the operation from a request URL, optionally preceded by its method (e.g. 'GET https://api.example.com/users/42'), and fill in its path and query arguments; the operation ID is then left out of the args"`
+	Find               string   `usage:"Find the operation by words from its ID, summary, tags, or description (e.g. 'create user') and run it if it's the only match; the operation ID is then left out of the args (see above)"`
+	FindThreshold      string   `usage:"Minimum similarity, from 0 to 1, of each word of --find to a word of an operation for it to match" default:"0.8"`
+	IgnoreCase         bool     `usage:"Match the operation ID case-insensitively"`
+	LenientJSON        bool     `usage:"Allow comments and trailing commas in JSON spec files"`
+	Recursive          bool     `usage:"Also search the subdirectories of directories given as files"`
+	FailFast           bool     `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+	FileFor            []string `usage:"File to take an operation from when several files define it, as operationID=file (can be repeated)" split:"false"`
+	AllServers         bool     `usage:"Send the request to each of the operation's servers and print the status and latency of each instead of the response"`
+	ValidateExamples   bool     `usage:"Instead of sending the request, check the operation's JSON response examples against its response schemas and print the results"`
+	PaginateParam      string   `usage:"Query parameter argument that selects the page; the operation is run for each page and the items of all pages are printed as one array (see above)"`
+	PaginateOffset     bool     `usage:"Advance the --paginate-param parameter by the number of items received instead of by one (automatic for parameters named offset or skip)"`
+	ItemsPath          string   `usage:"gjson path of the array of items in each page's response body (defaults to the whole body)"`
+	TotalPath          string   `usage:"gjson path of the total number of items in each page's response body; stops paginating once that many have been received"`
+	HasMorePath        string   `usage:"gjson path of a boolean in each page's response body that is true while there are more pages"`
+	MaxPages           int      `usage:"Most pages to request with --paginate-param (0 for no limit)" default:"100"`
+	StrictDuplicates   bool     `usage:"Fail if the operation is defined differently in several files, instead of using the first one"`
+	StrictFormats      bool     `usage:"Also check the formats that aren't checked by default: the ranges of int32, int64, float, and double, and base64 for byte"`
+	Interactive        bool     `usage:"Prompt for missing required arguments when running in a terminal"`
+	Query              []string `usage:"Extra query parameter to add to the request, as name=value (can be repeated)" split:"false"`
+	ServerVar          []string `usage:"Value of a variable in the operation's server URL, as name=value (can be repeated)" split:"false"`
+	BaseURL            string   `usage:"URL to send the request to instead of the operation's server, joined with the operation's path (e.g. http://localhost:8080/v1)" name:"base-url"`
+	ExpandEnv          bool     `usage:"Replace ${NAME} in argument values with environment variables (see above)"`
+	Defaults           string   `usage:"JSON file mapping operation IDs (or * for all operations) to default arguments; passed arguments take precedence"`
+	Example            string   `usage:"Name of an example from the operation to use as the base for the arguments"`
+	FieldFile          []string `usage:"File to upload as a multipart field, as fieldname=path (can be repeated, also for the same field)" split:"false"`
+	BoolFormat         string   `usage:"Values to use for true and false in query parameters, as true/false (e.g. 1/0 or yes/no)" default:"true/false"`
+	ArrayDelimiter     []string `usage:"Delimiter to join array query parameters with, as name=delimiter for one parameter or just the delimiter for all; overrides the parameter's style (can be repeated)" split:"false"`
+	RepeatHeader       []string `usage:"Header parameter whose array value is sent as one header line per item instead of comma-separated, or * for all (can be repeated)" split:"false"`
+	NoQueryEncode      bool     `usage:"Send query parameter values as they are given, without percent-encoding them, for values that are already encoded"`
+	UserAgent          string   `usage:"User-Agent header to send (defaults to openapi-cli/<version>)"`
+	Accept             string   `usage:"Accept header to send, verbatim (e.g. 'application/json, text/csv;q=0.5'); defaults to preferring JSON when the operation has several response media types"`
+	OutputFile         string   `usage:"Write the response body to this file instead of stdout"`
+	OutputHeadersFile  string   `usage:"Write the response headers to this file as a JSON object that maps each header name to its values"`
+	HARFile            string   `usage:"Write the request, the response, and the timings to this file as an HTTP Archive (HAR)" name:"har-file"`
+	HTTPFile           string   `usage:"Append the request to this .http file, for the VS Code REST Client or the JetBrains HTTP client" name:"http-file"`
+	Head               bool     `usage:"Send a HEAD request instead of the operation's method and print only the status and response headers"`
+	HTTP1              bool     `usage:"Only use HTTP/1.1" name:"http1"`
+	HTTP2              bool     `usage:"Require HTTP/2 for HTTPS requests" name:"http2"`
+	AcceptEncoding     string   `usage:"Value of the Accept-Encoding header; the response body is then output as it is received, without decompressing it (see above)"`
+	NoAutoDecompress   bool     `usage:"Don't ask for a gzip response and decompress it automatically (see above)"`
+	Header             []string `usage:"Extra request header, as 'Name: value' (can be repeated)" split:"false"`
+	AWSSigV4           bool     `usage:"Sign the request with AWS Signature Version 4, using credentials from the AWS_* environment variables" name:"aws-sigv4"`
+	AWSRegion          string   `usage:"AWS region for --aws-sigv4 (defaults to $AWS_REGION or $AWS_DEFAULT_REGION)" name:"aws-region"`
+	AWSService         string   `usage:"AWS service for --aws-sigv4" name:"aws-service" default:"execute-api"`
+	RequestContentType string   `usage:"Content-Type header to send with the request body (e.g. application/vnd.api+json), overriding the declared media type"`
+	CompressRequest    bool     `usage:"Gzip the request body and send it with Content-Encoding: gzip"`
+	PreRequestHook     string   `usage:"Command that receives the request as JSON on stdin and prints headers to set on it (see above)"`
+	Prefer             string   `usage:"Value of the Prefer header (e.g. return=minimal or respond-async)"`
+	IfMatch            string   `usage:"Value of the If-Match header"`
+	IfNoneMatch        string   `usage:"Value of the If-None-Match header"`
+	RateLimit          string   `usage:"Maximum number of requests to send per second (e.g. 5 or 0.5), including follow-up requests"`
+	Profile            string   `usage:"Name of a profile from the profiles file to apply before the other flags (see above)"`
+	ProfileFile        string   `usage:"JSON file mapping profile names to settings (defaults to $OPENAPI_CLI_PROFILES or openapi-cli/profiles.json in the user config directory)"`
+
+	NoDeprecationWarning bool   `usage:"Don't warn about the use of deprecated operations and parameters"`
+	TokenCommand         string `usage:"Command whose output is the bearer token, like 'gcloud auth print-access-token'; it is run once, when a token is first needed (see above)"`
+	NoEnvAuth            bool   `usage:"Don't send credentials from the OPENAPI_BEARER, OPENAPI_QUERY_KEY, OPENAPI_API_KEY, and OPENAPI_AUTH_<SCHEME> environment variables"`
+
+	ShowRequestID   bool     `usage:"Print the request ID from the response headers to stderr"`
+	RequestIDHeader []string `usage:"Response header containing the request ID (defaults to common request ID headers)" name:"request-id-header"`
+	PrintURL        bool     `usage:"Print the final request URL to stderr" name:"print-url"`
+}
+
+// defaultRequestIDHeaders are the response headers checked for a request ID, in order.
+var defaultRequestIDHeaders = []string{
+	"X-Request-Id",
+	"X-Amzn-RequestId",
+	"X-Amz-Request-Id",
+	"X-Correlation-Id",
+	"X-Trace-Id",
+	"Request-Id",
 }
 
-func (r *Run) Run(_ *cobra.Command, args []string) error {
-	if len(args) < 3 {
+func (r *Run) Customize(cmd *cobra.Command) {
+	cmd.Long = `Run an operation from one of the OpenAPI files.
+
+The --prefer, --if-match, and --if-none-match flags are shortcuts for setting the
+Prefer, If-Match, and If-None-Match request headers. Any header can be set with --header,
+which takes precedence over the shortcuts and over the headers that go with the operation's
+parameters and request body, like Content-Type.
+
+By default, requests ask for a gzip response with Accept-Encoding, and a gzip response is
+decompressed before it is output. --no-auto-decompress turns this off, so no Accept-Encoding
+is sent unless it is given. With --accept-encoding, the header is sent as it is given and the
+response body is output exactly as it is received, which keeps a compressed asset compressed
+for --output-file.
+
+Credentials for the operation's security schemes are read from the environment: OPENAPI_BEARER
+for bearer tokens, OPENAPI_QUERY_KEY for API keys in the query, and OPENAPI_API_KEY for API keys
+in a header or cookie. OPENAPI_AUTH_<SCHEME>, the scheme's name in upper case with underscores for
+other characters, sets the credential of one scheme, which lets a request that needs two API keys
+send different values. When a security requirement combines several schemes, like a bearer token
+and an X-Api-Key header, all of them are sent; the first requirement that has a credential for
+each of its schemes is used. --no-env-auth turns this off.
+
+With --token-command, the bearer token comes from a credential helper instead of OPENAPI_BEARER,
+for tokens that expire too soon to be kept in the environment. The command is run with sh -c
+the first time an operation needs a bearer token, and its output, trimmed of whitespace, is
+used for every request the command makes, including pages. The command runs with
+your permissions and environment, so only use commands you trust, and don't build them from
+untrusted input. The token is only kept in memory, but like any Authorization header, it is
+written to --http-file and --har-file and passed to --pre-request-hook.
+
+The --pre-request-hook command is run with sh -c right before the request is sent, after
+any other signing. It receives the request as JSON on stdin, where bodyBase64 is the exact
+bytes of the body, for bodies that aren't text, like the ones sent with --compress-request or a
+multipart body with a binary file:
+
+  {"method": "POST", "url": "https://...", "headers": {"Name": ["value"]}, "body": "...", "bodyBase64": "..."}
+
+It must print the headers to set as JSON on stdout. An empty value removes the header:
+
+  {"headers": {"Authorization": "HMAC ...", "X-Unwanted": ""}}
+
+With --args-from-stdin, each line of stdin is an argument as name=value, and the value is
+converted to the type of the argument in the operation's schema. Nested properties are named
+with dots, repeating the name of an array argument adds an item, and objects are given as JSON:
+
+  petId=42
+  requestBodyContent.name=Rex
+  requestBodyContent.tags=small
+  requestBodyContent.tags=brown
+
+With --expand-env, ${NAME} in the string values of the arguments, including the ones from
+--defaults and --example, is replaced with the value of the environment variable NAME before
+the arguments are validated, so the expanded values must match the schema. Use $${ for a
+literal ${. It is an error if a referenced variable isn't set.
+
+With --paginate-param, the operation is run once for each page of its results, with the
+page parameter starting from its value in the arguments, or from 1 (0 for offsets), and the
+items of all the pages are printed as one JSON array. Pages are requested until a page has
+no items, --total-path items have been received, --has-more-path is false, or --max-pages
+is reached. For example, for a response like {"data": [...], "total": 250}:
+
+  --paginate-param page --items-path data --total-path total
+
+With --find, the operation is found by words instead of its ID. Each word must be similar to a
+word of an operation's ID, summary, tags, or description, allowing for typos and plurals, and
+IDs like createUser are split into their words. If exactly one operation matches, it is run;
+if several do, they are printed with their scores and the command fails. --find-threshold sets
+how similar the words must be, from 0 to 1, where 1 only matches exact words:
+
+  run --find "create user" '{"name": "Rex"}' api.yaml
+
+With --profile, the settings of the named profile are applied first, and the other flags take
+precedence over them. The profiles file maps profile names to headers, server variables, extra
+query parameters, a base URL, and environment variables such as OPENAPI_BEARER:
+
+  {"staging": {"headers": {"X-Tenant": "acme"}, "serverVars": {"environment": "staging"},
+               "query": {"debug": "1"}, "env": {"OPENAPI_BEARER": "..."}},
+   "local": {"baseUrl": "http://localhost:8080"}}`
+}
+
+func (r *Run) Run(_ *cobra.Command, args []string) (retErr error) {
+	// The operation ID is left out of the args when it comes from --operation-from-url or --find,
+	// and the input is left out when it comes from --input-file or --args-from-stdin.
+	var operationID, input string
+	if r.InputFile != "" && r.ArgsFromStdin {
+		return fmt.Errorf("--input-file and --args-from-stdin cannot be used together")
+	}
+	if r.OperationFromURL != "" && r.Find != "" {
+		return fmt.Errorf("--operation-from-url and --find cannot be used together")
+	}
+	if r.OperationFromURL == "" && r.Find == "" {
+		if len(args) == 0 {
+			return fmt.Errorf("not enough args")
+		}
+		operationID, args = args[0], args[1:]
+	}
+	if r.InputFile != "" {
+		data, err := os.ReadFile(r.InputFile)
+		if err != nil {
+			return fmt.Errorf("failed to read input file: %w", err)
+		}
+		input = string(data)
+	} else if !r.ArgsFromStdin {
+		if len(args) == 0 {
+			return fmt.Errorf("not enough args")
+		}
+		input, args = args[0], args[1:]
+	}
+	if len(args) == 0 {
 		return fmt.Errorf("not enough args")
 	}
+	files, err := expandFiles(args, r.Recursive)
+	if err != nil {
+		return err
+	}
 
-	operationID := args[0]
-	input := args[1]
-	files := args[2:]
+	opts, err := r.runOptions()
+	if err != nil {
+		return err
+	}
 
-	for _, file := range files {
-		output, found, err := openapi.Run(operationID, file, input)
+	if r.HTTPFile != "" {
+		httpFile, err := os.OpenFile(r.HTTPFile, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+		if err != nil {
+			return fmt.Errorf("failed to open HTTP file: %w", err)
+		}
+		defer httpFile.Close()
+		opts.HTTPFile = httpFile
+	}
+
+	if r.HARFile != "" {
+		harFile, err := os.Create(r.HARFile)
+		if err != nil {
+			return fmt.Errorf("failed to create HAR file: %w", err)
+		}
+		// Every request of the command goes into one HAR, which is written when the command is done,
+		// even if it failed.
+		opts.HAR = openapi.NewHARArchive()
+		defer func() {
+			writeErr := opts.HAR.Write(harFile)
+			if closeErr := harFile.Close(); writeErr == nil && closeErr != nil {
+				writeErr = fmt.Errorf("failed to write HAR file: %w", closeErr)
+			}
+			if retErr == nil {
+				retErr = writeErr
+			}
+		}()
+	}
+
+	if r.OperationFromURL != "" {
+		if operationID, input, err = r.resolveOperationFromURL(input, files, &opts); err != nil {
+			return err
+		}
+	}
+
+	if r.Find != "" {
+		if operationID, err = r.findOperation(files); err != nil {
+			return err
+		}
+	}
+
+	if files, err = r.operationFiles(operationID, files); err != nil {
+		return err
+	}
+
+	var stdinArgs string
+	if r.ArgsFromStdin {
+		data, err := io.ReadAll(os.Stdin)
 		if err != nil {
+			return fmt.Errorf("failed to read arguments from stdin: %w", err)
+		}
+		stdinArgs = string(data)
+	}
+
+	search := fileSearch{failFast: r.FailFast}
+	for _, file := range files {
+		if r.ArgsFromStdin {
+			schema, _, found, err := openapi.GetSchema(operationID, file, openapi.SchemaOptions{BodyIsRoot: r.BodyIsRoot, IgnoreCase: r.IgnoreCase, LenientJSON: r.LenientJSON})
+			if search.skip(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to get schema for operation %s in file %s: %w", operationID, file, err)
+			}
+			if !found {
+				continue
+			}
+			if input, err = openapi.KeyValueArgs(schema, input, strings.NewReader(stdinArgs)); err != nil {
+				return err
+			}
+		}
+
+		if r.Interactive && isTerminal(os.Stdin) {
+			schema, _, found, err := openapi.GetSchema(operationID, file, openapi.SchemaOptions{BodyIsRoot: r.BodyIsRoot, IgnoreCase: r.IgnoreCase, LenientJSON: r.LenientJSON})
+			if search.skip(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to get schema for operation %s in file %s: %w", operationID, file, err)
+			}
+			if found {
+				if input, err = openapi.FillFixedArgs(schema, input); err != nil {
+					return err
+				}
+				if input, err = promptForMissingArgs(schema, input, os.Stdin, os.Stderr); err != nil {
+					return err
+				}
+			}
+		}
+
+		if r.ValidateExamples {
+			found, err := validateResponseExamples(operationID, file, opts)
+			if search.skip(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to validate response examples of operation %s in file %s: %w", operationID, file, err)
+			}
+			if found {
+				return nil
+			}
+			continue
+		}
+
+		if r.AllServers {
+			found, err := runAllServers(operationID, file, input, opts)
+			if search.skip(err) {
+				continue
+			} else if err != nil {
+				return fmt.Errorf("failed to run operation %s in file %s: %w", operationID, file, err)
+			}
+			if found {
+				return nil
+			}
+			continue
+		}
+
+		var (
+			resp  openapi.Response
+			found bool
+		)
+		if r.PaginateParam != "" {
+			resp, found, err = openapi.Paginate(operationID, file, input, opts, openapi.PaginateOptions{
+				Param:       r.PaginateParam,
+				Offset:      r.PaginateOffset,
+				ItemsPath:   r.ItemsPath,
+				TotalPath:   r.TotalPath,
+				HasMorePath: r.HasMorePath,
+				MaxPages:    r.MaxPages,
+			})
+		} else {
+			resp, found, err = openapi.RunResponse(operationID, file, input, opts)
+		}
+		if search.skip(err) {
+			continue
+		} else if err != nil {
 			return fmt.Errorf("failed to run operation %s in file %s: %w", operationID, file, err)
 		}
 
 		if found {
-			fmt.Println(output)
+			if r.PrintURL {
+				_, _ = fmt.Fprintln(os.Stderr, resp.URL)
+			}
+			if r.ShowRequestID {
+				r.printRequestID(resp.Header)
+			}
+			if r.OutputHeadersFile != "" {
+				if err := writeHeadersFile(r.OutputHeadersFile, resp.Header); err != nil {
+					return err
+				}
+			}
+			if r.Head {
+				printStatusAndHeaders(resp)
+			} else if err := r.writeOutput(resp); err != nil {
+				return err
+			}
+
+			if resp.StatusCode >= 400 {
+				return &httpStatusError{statusCode: resp.StatusCode, retryAfter: resp.RetryAfter}
+			}
 			return nil
 		}
 	}
 
-	return fmt.Errorf("operation %s not found in any file", operationID)
+	return search.notFound(operationID, files)
+}
+
+// operationFiles returns the files to look for the operation in: the file given for it with --file-for,
+// or else all the files. With --strict-duplicates, it is an error if several of the files define the operation
+// differently, since the first one might not be the API that was meant.
+func (r *Run) operationFiles(operationID string, files []string) ([]string, error) {
+	for _, f := range r.FileFor {
+		id, file, ok := strings.Cut(f, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid file for operation %q: expected operationID=file", f)
+		}
+		if id == operationID {
+			return []string{file}, nil
+		}
+	}
+	if !r.StrictDuplicates {
+		return files, nil
+	}
+
+	var (
+		definingFiles []string
+		definitions   = map[string]bool{}
+	)
+	for _, file := range files {
+		schema, info, found, err := openapi.GetSchema(operationID, file, openapi.SchemaOptions{IgnoreCase: r.IgnoreCase, LenientJSON: r.LenientJSON})
+		if err != nil || !found {
+			// Files that fail to load are reported by the search for the operation.
+			continue
+		}
+		definingFiles = append(definingFiles, file)
+		definitions[info.Method+" "+info.Server+info.Path+"\n"+schema] = true
+	}
+	if len(definitions) > 1 {
+		return nil, fmt.Errorf("operation %s is defined differently in several files: %s (use --file-for to pick one)", operationID, strings.Join(definingFiles, ", "))
+	}
+	return files, nil
+}
+
+// validateResponseExamples checks the operation's response examples against its response schemas and prints the results as JSON.
+// It returns an error if any of the examples doesn't match its schema.
+func validateResponseExamples(operationID, file string, opts openapi.RunOptions) (bool, error) {
+	results, found, err := openapi.ValidateResponseExamples(operationID, file, openapi.SchemaOptions{IgnoreCase: opts.IgnoreCase, LenientJSON: opts.LenientJSON})
+	if err != nil || !found {
+		return found, err
+	}
+
+	output, err := json.MarshalIndent(results, "", "    ")
+	if err != nil {
+		return true, fmt.Errorf("failed to marshal example results: %w", err)
+	}
+	fmt.Println(string(output))
+
+	var invalid int
+	for _, result := range results {
+		if !result.Valid {
+			invalid++
+		}
+	}
+	if invalid > 0 {
+		return true, fmt.Errorf("%d of %d response examples don't match their schemas", invalid, len(results))
+	}
+	return true, nil
+}
+
+// serverResult is the outcome of sending a request to one of an operation's servers.
+type serverResult struct {
+	// Server is the server's URL as it is declared, without its variables filled in.
+	Server     string `json:"server"`
+	URL        string `json:"url,omitempty"`
+	StatusCode int    `json:"status,omitempty"`
+	// LatencyMS is the time from sending the request until the response headers arrived, in milliseconds.
+	LatencyMS float64 `json:"latencyMs"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// latencyTransport adds up how long its round trips take, until the response headers arrive.
+type latencyTransport struct {
+	next    http.RoundTripper
+	latency time.Duration
+}
+
+func (t *latencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+	t.latency += time.Since(start)
+	return resp, err
+}
+
+// runAllServers sends the request to each of the operation's servers in turn and prints the results as JSON.
+// It returns an error if any of the requests failed or got an error status.
+func runAllServers(operationID, file, input string, opts openapi.RunOptions) (bool, error) {
+	_, opInfo, found, err := openapi.GetSchema(operationID, file, openapi.SchemaOptions{IgnoreCase: opts.IgnoreCase, ServerVariables: opts.ServerVariables, LenientJSON: opts.LenientJSON})
+	if err != nil || !found {
+		return false, err
+	}
+	if len(opInfo.Servers) == 0 {
+		return true, fmt.Errorf("operation %s doesn't declare any servers", operationID)
+	}
+
+	client := http.Client{}
+	if opts.Client != nil {
+		client = *opts.Client
+	}
+	transport := &latencyTransport{next: client.Transport}
+	if transport.next == nil {
+		transport.next = http.DefaultTransport
+	}
+	client.Transport = transport
+	opts.Client = &client
+
+	var (
+		results []serverResult
+		failed  int
+	)
+	for i, server := range opInfo.Servers {
+		opts.ServerIndex = i
+		transport.latency = 0
+		resp, _, err := openapi.RunResponse(operationID, file, input, opts)
+		result := serverResult{Server: server, LatencyMS: float64(transport.latency.Microseconds()) / 1000}
+		switch {
+		case err != nil:
+			result.Error = err.Error()
+			failed++
+		case resp.StatusCode >= 400:
+			failed++
+			fallthrough
+		default:
+			result.URL = resp.URL
+			result.StatusCode = resp.StatusCode
+		}
+		results = append(results, result)
+	}
+
+	output, err := json.MarshalIndent(results, "", "    ")
+	if err != nil {
+		return true, fmt.Errorf("failed to marshal server results: %w", err)
+	}
+	fmt.Println(string(output))
+
+	if failed > 0 {
+		return true, fmt.Errorf("%d of %d servers failed", failed, len(results))
+	}
+	return true, nil
+}
+
+// findOperation returns the ID of the only operation in the files that matches --find. If several operations
+// match, they are printed as JSON, best first, so that one of them can be picked.
+func (r *Run) findOperation(files []string) (string, error) {
+	threshold, err := strconv.ParseFloat(r.FindThreshold, 64)
+	if err != nil || threshold < 0 || threshold > 1 {
+		return "", fmt.Errorf("invalid find threshold %q: must be a number from 0 to 1", r.FindThreshold)
+	}
+
+	var (
+		matches []openapi.OperationMatch
+		seen    = map[string]bool{}
+	)
+	search := fileSearch{failFast: r.FailFast}
+	for _, file := range files {
+		fileMatches, err := openapi.FindOperations(file, r.Find, threshold, r.LenientJSON)
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("failed to search file %s: %w", file, err)
+		}
+		// An operation in several files is one candidate, since it is run from the first file that has it.
+		for _, match := range fileMatches {
+			if !seen[match.OperationID] {
+				seen[match.OperationID] = true
+				matches = append(matches, match)
+			}
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("no operation matches %q in any file", r.Find)
+	case 1:
+		return matches[0].OperationID, nil
+	}
+
+	output, err := json.MarshalIndent(matches, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal matching operations: %w", err)
+	}
+	fmt.Println(string(output))
+	return "", fmt.Errorf("%d operations match %q; run one of them by its ID or raise --find-threshold", len(matches), r.Find)
+}
+
+// resolveOperationFromURL finds the operation that a request URL, optionally preceded by its method, was made for
+// in the first file that has one. Files that fail to load are skipped unless --fail-fast is set. It returns the
+// operation ID and the input with the path and query arguments from the URL added. Arguments in the input take
+// precedence, and query parameters that the operation doesn't declare are added to the options' extra query parameters.
+func (r *Run) resolveOperationFromURL(input string, files []string, opts *openapi.RunOptions) (string, string, error) {
+	var (
+		method     string
+		requestURL = r.OperationFromURL
+	)
+	if fields := strings.Fields(requestURL); len(fields) == 2 {
+		method, requestURL = fields[0], fields[1]
+	}
+
+	search := fileSearch{failFast: r.FailFast}
+	for _, file := range files {
+		match, found, err := openapi.MatchURL(file, method, requestURL, r.LenientJSON)
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return "", "", fmt.Errorf("failed to match URL %s in file %s: %w", requestURL, file, err)
+		}
+		if !found {
+			continue
+		}
+
+		args := map[string]any{}
+		if input != "" {
+			decoder := json.NewDecoder(strings.NewReader(input))
+			decoder.UseNumber()
+			if err := decoder.Decode(&args); err != nil {
+				return "", "", fmt.Errorf("failed to parse input: %w", err)
+			}
+		}
+		for name, value := range match.Args {
+			if _, ok := args[name]; !ok {
+				args[name] = value
+			}
+		}
+		for name, values := range match.ExtraQuery {
+			opts.Query[name] = append(opts.Query[name], values...)
+		}
+
+		result, err := json.Marshal(args)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to marshal input: %w", err)
+		}
+		return match.OperationID, string(result), nil
+	}
+
+	return "", "", fmt.Errorf("no operation matches URL %s in any file%s", requestURL, describeLoadErrors(search.loadErrors))
+}
+
+// printStatusAndHeaders prints the response status followed by the response headers, sorted by name.
+func printStatusAndHeaders(resp openapi.Response) {
+	fmt.Println(resp.StatusCode, http.StatusText(resp.StatusCode))
+
+	names := make([]string, 0, len(resp.Header))
+	for name := range resp.Header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, value := range resp.Header[name] {
+			fmt.Printf("%s: %s\n", name, value)
+		}
+	}
+}
+
+// writeHeadersFile writes the response headers to the file as a JSON object that maps each header name to its values.
+func writeHeadersFile(file string, header http.Header) error {
+	if header == nil {
+		header = http.Header{}
+	}
+	data, err := json.MarshalIndent(header, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal response headers: %w", err)
+	}
+	if err := os.WriteFile(file, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write headers file: %w", err)
+	}
+	return nil
+}
+
+// writeOutput writes the response body to the output file or stdout.
+// Binary bodies are summarized instead of printed when stdout is a terminal, so that they don't corrupt it.
+func (r *Run) writeOutput(resp openapi.Response) error {
+	if r.OutputFile != "" {
+		if err := os.WriteFile(r.OutputFile, []byte(resp.Body), 0644); err != nil {
+			return fmt.Errorf("failed to write output file: %w", err)
+		}
+		return nil
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if !isBinary(contentType, resp.Body) {
+		fmt.Println(resp.Body)
+		return nil
+	}
+
+	if isTerminal(os.Stdout) {
+		if contentType == "" {
+			contentType = "unknown content type"
+		}
+		fmt.Printf("<binary %d bytes, %s>\n", len(resp.Body), contentType)
+		_, _ = fmt.Fprintln(os.Stderr, "Use --output-file to save the response body to a file.")
+		return nil
+	}
+
+	_, err := io.WriteString(os.Stdout, resp.Body)
+	return err
+}
+
+// isBinary returns whether a response body should be treated as binary data rather than text.
+func isBinary(contentType, body string) bool {
+	if mediaType, _, err := mime.ParseMediaType(contentType); err == nil {
+		switch {
+		case strings.HasPrefix(mediaType, "text/"),
+			strings.HasSuffix(mediaType, "json"),
+			strings.HasSuffix(mediaType, "xml"),
+			strings.HasSuffix(mediaType, "yaml"),
+			mediaType == "application/javascript",
+			mediaType == "application/x-www-form-urlencoded":
+		default:
+			return true
+		}
+	}
+	return !utf8.ValidString(body)
+}
+
+// printRequestID prints the first request ID header found in the response headers to stderr.
+func (r *Run) printRequestID(header http.Header) {
+	names := r.RequestIDHeader
+	if len(names) == 0 {
+		names = defaultRequestIDHeaders
+	}
+
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "%s: %s\n", name, value)
+			return
+		}
+	}
+}
+
+// httpClient returns the HTTP client to use for the request, based on the protocol and compression flags.
+// It returns nil to use the default client when no protocol is forced and decompression is left on.
+func (r *Run) httpClient() (*http.Client, error) {
+	if r.HTTP1 && r.HTTP2 {
+		return nil, fmt.Errorf("--http1 and --http2 cannot be used together")
+	}
+	if !r.HTTP1 && !r.HTTP2 && !r.NoAutoDecompress {
+		return nil, nil
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DisableCompression = r.NoAutoDecompress
+	if r.HTTP1 {
+		// A non-nil, empty TLSNextProto disables HTTP/2.
+		transport.ForceAttemptHTTP2 = false
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+		transport.TLSClientConfig = &tls.Config{NextProtos: []string{"http/1.1"}}
+	} else if r.HTTP2 {
+		// Only offer HTTP/2 during TLS negotiation.
+		transport.ForceAttemptHTTP2 = true
+		transport.TLSClientConfig = &tls.Config{NextProtos: []string{"h2"}}
+	}
+
+	return &http.Client{Transport: transport}, nil
+}
+
+// runOptions builds the options for openapi.Run from the command's flags.
+func (r *Run) runOptions() (openapi.RunOptions, error) {
+	var p profile
+	if r.Profile != "" {
+		var err error
+		if p, err = readProfile(r.ProfileFile, r.Profile); err != nil {
+			return openapi.RunOptions{}, err
+		}
+		if err := p.applyEnv(); err != nil {
+			return openapi.RunOptions{}, err
+		}
+	}
+
+	opts := openapi.RunOptions{
+		Query:              url.Values{},
+		BodyIsRoot:         r.BodyIsRoot,
+		NoEnvAuth:          r.NoEnvAuth,
+		IgnoreCase:         r.IgnoreCase,
+		LenientJSON:        r.LenientJSON,
+		StrictFormats:      r.StrictFormats,
+		CompressRequest:    r.CompressRequest,
+		RequestContentType: r.RequestContentType,
+		Example:            r.Example,
+		ExpandEnv:          r.ExpandEnv,
+		FieldFiles:         map[string][]string{},
+		UserAgent:          r.UserAgent,
+		NoQueryEncode:      r.NoQueryEncode,
+		RepeatedHeaders:    r.RepeatHeader,
+		Accept:             r.Accept,
+	}
+
+	if r.Head {
+		opts.Method = http.MethodHead
+	}
+	if r.PaginateParam != "" && (r.Head || r.AllServers) {
+		return openapi.RunOptions{}, fmt.Errorf("--paginate-param can't be used with --head or --all-servers")
+	}
+
+	if r.AWSSigV4 {
+		signer, err := openapi.NewAWSSigV4SignerFromEnv(r.AWSRegion, r.AWSService)
+		if err != nil {
+			return openapi.RunOptions{}, err
+		}
+		opts.Signers = append(opts.Signers, signer)
+	}
+	if r.PreRequestHook != "" {
+		opts.Signers = append(opts.Signers, openapi.CommandHook{Command: r.PreRequestHook})
+	}
+	if r.TokenCommand != "" {
+		if r.NoEnvAuth {
+			return openapi.RunOptions{}, fmt.Errorf("--token-command and --no-env-auth cannot be used together")
+		}
+		opts.BearerToken = openapi.NewTokenCommand(r.TokenCommand).Token
+	}
+
+	client, err := r.httpClient()
+	if err != nil {
+		return openapi.RunOptions{}, err
+	}
+	opts.Client = client
+
+	if r.RateLimit != "" {
+		rate, err := strconv.ParseFloat(r.RateLimit, 64)
+		if err != nil {
+			return openapi.RunOptions{}, fmt.Errorf("invalid rate limit %q: %w", r.RateLimit, err)
+		}
+		if opts.RateLimiter, err = openapi.NewRateLimiter(rate); err != nil {
+			return openapi.RunOptions{}, err
+		}
+	}
+
+	opts.Headers = http.Header{}
+	for name, value := range map[string]string{
+		"Prefer":          r.Prefer,
+		"If-Match":        r.IfMatch,
+		"If-None-Match":   r.IfNoneMatch,
+		"Accept-Encoding": r.AcceptEncoding,
+	} {
+		if value != "" {
+			opts.Headers.Set(name, value)
+		}
+	}
+	for name, value := range p.Headers {
+		opts.Headers.Set(name, value)
+	}
+	headers := http.Header{}
+	for _, h := range r.Header {
+		name, value, ok := strings.Cut(h, ":")
+		if !ok {
+			return openapi.RunOptions{}, fmt.Errorf("invalid header %q: expected 'Name: value'", h)
+		}
+		headers.Add(strings.TrimSpace(name), strings.TrimSpace(value))
+	}
+	for name, values := range headers {
+		opts.Headers[name] = values
+	}
+	if !r.NoDeprecationWarning {
+		opts.Warnings = os.Stderr
+	}
+
+	if r.Defaults != "" {
+		defaults, err := readDefaults(r.Defaults)
+		if err != nil {
+			return openapi.RunOptions{}, err
+		}
+		opts.Defaults = defaults
+	}
+
+	for _, q := range r.Query {
+		name, value, ok := strings.Cut(q, "=")
+		if !ok {
+			return openapi.RunOptions{}, fmt.Errorf("invalid query parameter %q: expected name=value", q)
+		}
+		opts.Query.Add(name, value)
+	}
+	for name, value := range p.Query {
+		if !opts.Query.Has(name) {
+			opts.Query.Set(name, value)
+		}
+	}
+
+	serverVariables, err := parseServerVariables(r.ServerVar)
+	if err != nil {
+		return openapi.RunOptions{}, err
+	}
+	for name, value := range p.ServerVars {
+		if _, ok := serverVariables[name]; !ok {
+			if serverVariables == nil {
+				serverVariables = map[string]string{}
+			}
+			serverVariables[name] = value
+		}
+	}
+	opts.ServerVariables = serverVariables
+
+	opts.BaseURL = p.BaseURL
+	if r.BaseURL != "" {
+		opts.BaseURL = r.BaseURL
+	}
+	if opts.BaseURL != "" {
+		if r.AllServers {
+			return openapi.RunOptions{}, fmt.Errorf("--base-url can't be used with --all-servers")
+		}
+		if u, err := url.Parse(opts.BaseURL); err != nil || u.Scheme == "" || u.Host == "" {
+			return openapi.RunOptions{}, fmt.Errorf("invalid base URL %q: expected an absolute URL like https://api.example.com", opts.BaseURL)
+		}
+	}
+
+	trueValue, falseValue, ok := strings.Cut(r.BoolFormat, "/")
+	if !ok || trueValue == "" || falseValue == "" {
+		return openapi.RunOptions{}, fmt.Errorf("invalid bool format %q: expected true/false values separated by a slash", r.BoolFormat)
+	}
+	opts.BoolFormat = openapi.BoolFormat{True: trueValue, False: falseValue}
+
+	for _, d := range r.ArrayDelimiter {
+		if opts.ArrayDelimiters == nil {
+			opts.ArrayDelimiters = map[string]string{}
+		}
+		if name, delimiter, ok := strings.Cut(d, "="); ok {
+			opts.ArrayDelimiters[name] = delimiter
+		} else {
+			opts.ArrayDelimiters["*"] = d
+		}
+	}
+
+	for _, f := range r.FieldFile {
+		field, path, ok := strings.Cut(f, "=")
+		if !ok {
+			return openapi.RunOptions{}, fmt.Errorf("invalid field file %q: expected fieldname=path", f)
+		}
+		opts.FieldFiles[field] = append(opts.FieldFiles[field], path)
+	}
+
+	return opts, nil
+}
+
+// parseServerVariables parses server variables given as name=value. It returns nil if there are none.
+func parseServerVariables(values []string) (map[string]string, error) {
+	var vars map[string]string
+	for _, v := range values {
+		name, value, ok := strings.Cut(v, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid server variable %q: expected name=value", v)
+		}
+		if vars == nil {
+			vars = map[string]string{}
+		}
+		vars[name] = value
+	}
+	return vars, nil
+}
+
+// readDefaults reads a defaults file, which maps operation IDs to default arguments.
+func readDefaults(file string) (map[string]map[string]any, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open defaults file: %w", err)
+	}
+	defer f.Close()
+
+	var defaults map[string]map[string]any
+	decoder := json.NewDecoder(f)
+	decoder.UseNumber()
+	if err := decoder.Decode(&defaults); err != nil {
+		return nil, fmt.Errorf("failed to parse defaults file %s: %w", file, err)
+	}
+	return defaults, nil
 }
@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Sample struct {
+	LenientJSON bool `usage:"Allow comments and trailing commas in JSON spec files"`
+	FailFast    bool `usage:"Stop at the first file that fails to load, instead of looking for the operation in the other files"`
+}
+
+func (s *Sample) Run(_ *cobra.Command, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("not enough args")
+	}
+
+	operationID := args[0]
+	files := args[1:]
+
+	search := fileSearch{failFast: s.FailFast}
+	for _, file := range files {
+		sample, found, err := openapi.Sample(operationID, file, s.LenientJSON)
+		if search.skip(err) {
+			continue
+		} else if err != nil {
+			return fmt.Errorf("failed to generate sample for operation %s in file %s: %w", operationID, file, err)
+		}
+		if !found {
+			continue
+		}
+		fmt.Println(sample)
+		return nil
+	}
+
+	return search.notFound(operationID, files)
+}
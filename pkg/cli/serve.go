@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Serve struct {
+	Address     string `usage:"Address to listen on" default:"localhost:8080"`
+	LenientJSON bool   `usage:"Allow comments and trailing commas in JSON spec files"`
+}
+
+func (s *Serve) Run(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("expected exactly one file")
+	}
+
+	handler, err := openapi.NewMockHandler(args[0], s.LenientJSON)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:    s.Address,
+		Handler: handler,
+	}
+
+	go func() {
+		<-cmd.Context().Done()
+		_ = server.Shutdown(context.Background())
+	}()
+
+	_, _ = fmt.Fprintf(os.Stderr, "Serving mock responses for %s on %s\n", args[0], s.Address)
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("failed to serve: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+)
+
+// maxSuggestions is the most operation IDs suggested for an unknown operation ID.
+const maxSuggestions = 3
+
+// newNotFoundError returns the error for an operation that isn't found in any of the files,
+// with suggestions for the operation IDs in the files that are closest to it.
+func newNotFoundError(operationID string, files []string) *notFoundError {
+	return &notFoundError{
+		operationID: operationID,
+		suggestions: suggestOperationIDs(operationID, files),
+	}
+}
+
+// suggestOperationIDs returns the operation IDs in the files that are closest to operationID by edit distance,
+// ignoring case. Only IDs that are close enough to be a plausible typo are suggested.
+func suggestOperationIDs(operationID string, files []string) []string {
+	maxDistance := max(2, len(operationID)/3)
+
+	distances := map[string]int{}
+	for _, file := range files {
+		operations, err := openapi.List(file, openapi.ListOptions{})
+		if err != nil {
+			continue
+		}
+		for id := range operations.Operations {
+			if d := editDistance(strings.ToLower(operationID), strings.ToLower(id)); d <= maxDistance {
+				distances[id] = d
+			}
+		}
+	}
+
+	suggestions := make([]string, 0, len(distances))
+	for id := range distances {
+		suggestions = append(suggestions, id)
+	}
+	slices.SortFunc(suggestions, func(a, b string) int {
+		if distances[a] != distances[b] {
+			return distances[a] - distances[b]
+		}
+		return strings.Compare(a, b)
+	})
+	if len(suggestions) > maxSuggestions {
+		suggestions = suggestions[:maxSuggestions]
+	}
+	return suggestions
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	ar, br := []rune(a), []rune(b)
+	prev := make([]int, len(br)+1)
+	curr := make([]int, len(br)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ar); i++ {
+		curr[0] = i
+		for j := 1; j <= len(br); j++ {
+			cost := 1
+			if ar[i-1] == br[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+	return prev[len(br)]
+}
@@ -0,0 +1,67 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/openapi"
+	"github.com/spf13/cobra"
+)
+
+type Validate struct {
+	FailOnWarning bool `usage:"Fail if any file has warnings, not only if it has errors"`
+	LenientJSON   bool `usage:"Allow comments and trailing commas in JSON spec files"`
+	Recursive     bool `usage:"Also search the subdirectories of directories given as files"`
+}
+
+func (v *Validate) Customize(cmd *cobra.Command) {
+	cmd.Long = `Validate OpenAPI files and print the errors and warnings found in each of them as JSON.
+
+Errors are files that can't be loaded or that don't follow the OpenAPI specification. Warnings
+are problems that the specification allows but that keep part of the file from being used:
+operations without an operationId, operation IDs that are used more than once, and servers
+that requests can't be sent to, or no servers at all.
+
+The command fails if any file has errors, or with --fail-on-warning, if any file has warnings,
+so that it can be used to lint specs in CI.`
+}
+
+func (v *Validate) Run(_ *cobra.Command, args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf("no files provided")
+	}
+
+	files, err := expandFiles(args, v.Recursive)
+	if err != nil {
+		return err
+	}
+
+	var (
+		reports            []openapi.DocumentReport
+		withErrors, warned int
+	)
+	for _, file := range files {
+		report := openapi.ValidateDocument(file, v.LenientJSON)
+		if len(report.Errors) > 0 {
+			withErrors++
+		}
+		if len(report.Warnings) > 0 {
+			warned++
+		}
+		reports = append(reports, report)
+	}
+
+	output, err := json.MarshalIndent(reports, "", "    ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal validation reports: %w", err)
+	}
+	fmt.Println(string(output))
+
+	switch {
+	case withErrors > 0:
+		return fmt.Errorf("%d of %d file(s) have errors", withErrors, len(reports))
+	case v.FailOnWarning && warned > 0:
+		return fmt.Errorf("%d of %d file(s) have warnings", warned, len(reports))
+	}
+	return nil
+}
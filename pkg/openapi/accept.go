@@ -0,0 +1,45 @@
+package openapi
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// successContentTypes returns the media types of the operation's 2XX responses, sorted and without duplicates.
+func successContentTypes(operation *openapi3.Operation) []string {
+	if operation.Responses == nil {
+		return nil
+	}
+
+	var types []string
+	for code, response := range operation.Responses.Map() {
+		if !strings.HasPrefix(code, "2") || response == nil || response.Value == nil {
+			continue
+		}
+		for mediaType := range response.Value.Content {
+			types = append(types, mediaType)
+		}
+	}
+	slices.Sort(types)
+	return slices.Compact(types)
+}
+
+// defaultAccept returns an Accept header value for the media types that prefers JSON.
+// JSON media types are listed first with the default quality of 1, other media types get a quality
+// of 0.9, and wildcard media ranges get 0.1, so that any specific type is preferred over them.
+func defaultAccept(mediaTypes []string) string {
+	var jsonTypes, other, wildcard []string
+	for _, mediaType := range mediaTypes {
+		switch {
+		case strings.Contains(mediaType, "*"):
+			wildcard = append(wildcard, mediaType+";q=0.1")
+		case strings.Contains(mediaType, "json"):
+			jsonTypes = append(jsonTypes, mediaType)
+		default:
+			other = append(other, mediaType+";q=0.9")
+		}
+	}
+	return strings.Join(slices.Concat(jsonTypes, other, wildcard), ", ")
+}
@@ -0,0 +1,191 @@
+package openapi
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type failingSigner struct{}
+
+func (failingSigner) SignRequest(*http.Request) error {
+	return errors.New("signing failed")
+}
+
+// TestStreamedBodyIsClosedOnFailure checks that the goroutine writing a streamed body stops when the request
+// fails before the body is read, instead of blocking on the pipe forever.
+func TestStreamedBodyIsClosedOnFailure(t *testing.T) {
+	// The file is larger than what the pipe and the transport buffer, so the writer blocks until the body is closed.
+	upload := filepath.Join(t.TempDir(), "upload.bin")
+	if err := os.WriteFile(upload, make([]byte, 4<<20), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name string
+		opts RunOptions
+	}{
+		{"signer fails", RunOptions{Signers: []RequestSigner{failingSigner{}}}},
+		{"connection fails", RunOptions{}},
+		{"signer fails with gzip", RunOptions{Signers: []RequestSigner{failingSigner{}}, CompressRequest: true}},
+		{"connection fails with gzip", RunOptions{CompressRequest: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := runtime.NumGoroutine()
+			tt.opts.FieldFiles = map[string][]string{"file": {upload}}
+			if _, _, err := Run("upload", "testdata/upload.yaml", `{"requestBodyContent": {"name": "a"}}`, tt.opts); err == nil {
+				t.Fatal("expected an error")
+			}
+
+			deadline := time.Now().Add(2 * time.Second)
+			for runtime.NumGoroutine() > before {
+				if time.Now().After(deadline) {
+					t.Fatalf("%d goroutines are still running, want %d", runtime.NumGoroutine(), before)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		})
+	}
+}
+
+// recordingTransport records the requests sent through it, with their bodies, and responds to them with an empty 200 response.
+type recordingTransport struct {
+	requests []*http.Request
+	bodies   []string
+}
+
+func (rt *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, err
+		}
+	}
+	rt.requests = append(rt.requests, req)
+	rt.bodies = append(rt.bodies, string(body))
+	return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+}
+
+// recordRequests makes the default client send its requests to a recordingTransport until the test ends.
+func recordRequests(t *testing.T) *recordingTransport {
+	rt := &recordingTransport{}
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = rt
+	t.Cleanup(func() { http.DefaultClient.Transport = transport })
+	return rt
+}
+
+func TestRunHeadDoesNotRequireBody(t *testing.T) {
+	rt := recordRequests(t)
+
+	if _, _, err := Run("createPet", "testdata/head.yaml", `{}`, RunOptions{Method: http.MethodHead}); err != nil {
+		t.Fatal(err)
+	}
+	if len(rt.requests) != 1 || rt.requests[0].Method != http.MethodHead {
+		t.Fatalf("got requests %v, want one HEAD request", rt.requests)
+	}
+
+	// The body is still required for the operation's own method.
+	if _, _, err := Run("createPet", "testdata/head.yaml", `{}`, RunOptions{}); err == nil || !strings.Contains(err.Error(), "invalid arguments") {
+		t.Errorf("got error %v, want invalid arguments", err)
+	}
+}
+
+func TestRunWritesMultipartArrays(t *testing.T) {
+	rt := recordRequests(t)
+
+	dir := t.TempDir()
+	var files []string
+	for _, name := range []string{"a.txt", "b.txt"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, path)
+	}
+
+	args := `{"requestBodyContent": {"title": "notes", "tags": ["x", "y"], "metadata": ["m1", "m2"], "files": []}}`
+	opts := RunOptions{FieldFiles: map[string][]string{"files": files}}
+	if _, _, err := Run("uploadDocuments", "testdata/multipart.yaml", args, opts); err != nil {
+		t.Fatal(err)
+	}
+	parts := multipartParts(t, rt.requests[0], rt.bodies[0])
+
+	// The file fields are merged into the arguments, which sorts their properties, and the files come last.
+	want := []string{`metadata=["m1","m2"]`, "tags=x", "tags=y", "title=notes", "files=a.txt", "files=b.txt"}
+	if strings.Join(parts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got parts\n%s\nwant\n%s", strings.Join(parts, "\n"), strings.Join(want, "\n"))
+	}
+
+	// Without files, the parts are in the order of the arguments.
+	args = `{"requestBodyContent": {"title": "notes", "tags": ["x", "y"], "metadata": ["m1", "m2"]}}`
+	if _, _, err := Run("uploadDocuments", "testdata/multipart.yaml", args, RunOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	parts = multipartParts(t, rt.requests[1], rt.bodies[1])
+	want = []string{"title=notes", "tags=x", "tags=y", `metadata=["m1", "m2"]`}
+	if strings.Join(parts, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got parts\n%s\nwant\n%s", strings.Join(parts, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+// multipartParts returns the parts of a recorded multipart request body as name=value lines.
+func multipartParts(t *testing.T, req *http.Request, body string) []string {
+	t.Helper()
+	_, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parts []string
+	reader := multipart.NewReader(strings.NewReader(body), params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(part)
+		parts = append(parts, part.FormName()+"="+string(data))
+	}
+}
+
+// TestRunSendsEmptyBodyForBodylessPost checks that a POST without a body is sent with Content-Length: 0, also when
+// a signer reads and replaces the body, instead of with chunked encoding that some servers reject.
+func TestRunSendsEmptyBodyForBodylessPost(t *testing.T) {
+	sigV4 := &AWSSigV4Signer{AccessKeyID: "AKID", SecretAccessKey: "secret", Region: "us-east-1", Service: "execute-api"}
+	hook := CommandHook{Command: `cat > /dev/null; echo '{"headers": {"X-Signed": "yes"}}'`}
+	tests := []struct {
+		name    string
+		signers []RequestSigner
+	}{
+		{"no signers", nil},
+		{"aws sigv4", []RequestSigner{sigV4}},
+		{"pre-request hook", []RequestSigner{hook}},
+		{"aws sigv4 and pre-request hook", []RequestSigner{sigV4, hook}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := recordRequests(t)
+			if _, _, err := Run("cancelJob", "testdata/no-body.yaml", `{"id": "1"}`, RunOptions{Signers: tt.signers}); err != nil {
+				t.Fatal(err)
+			}
+			// The transport sends a chunked body when it doesn't know the length, which is the case for any body
+			// other than http.NoBody with a zero Content-Length.
+			req := rt.requests[0]
+			if req.ContentLength != 0 || len(req.TransferEncoding) > 0 || (req.Body != nil && req.Body != http.NoBody) {
+				t.Errorf("got Content-Length %d, Transfer-Encoding %v and body %T, want no body with Content-Length 0", req.ContentLength, req.TransferEncoding, req.Body)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// conditionalKeywords are JSON schema keywords that kin-openapi doesn't model, so they are kept as raw values
+// in the schema's extensions, with their references unresolved. Besides the conditional keywords themselves,
+// this includes prefixItems, which describes the items of tuple arrays.
+var conditionalKeywords = []string{"if", "then", "else", "dependentSchemas", "dependentRequired", "prefixItems"}
+
+// maxRawRefDepth limits how many references are inlined inside each other in raw schemas,
+// so that recursive schemas stay finite.
+const maxRawRefDepth = 8
+
+// componentSchemas returns the component schemas of the document, if it has any.
+func componentSchemas(t *openapi3.T) openapi3.Schemas {
+	if t.Components == nil {
+		return nil
+	}
+	return t.Components.Schemas
+}
+
+// removeConditionalRefs inlines the references to component schemas in the conditional keywords of the schema.
+// It also adds the dependentRequired and dependentSchemas keywords to the schema as draft-07 dependencies,
+// and prefixItems as draft-07 tuple items, since those are the forms that the JSON schema validator enforces.
+func removeConditionalRefs(schema *openapi3.Schema, components openapi3.Schemas) {
+	for _, keyword := range conditionalKeywords {
+		if value, ok := schema.Extensions[keyword]; ok {
+			schema.Extensions[keyword] = inlineRawRefs(value, components, 0)
+		}
+	}
+
+	addTupleItems(schema)
+
+	if _, ok := schema.Extensions["dependencies"]; ok {
+		return
+	}
+	dependencies := map[string]any{}
+	for _, keyword := range []string{"dependentRequired", "dependentSchemas"} {
+		if m, ok := schema.Extensions[keyword].(map[string]any); ok {
+			for name, dependency := range m {
+				dependencies[name] = dependency
+			}
+		}
+	}
+	if len(dependencies) > 0 {
+		schema.Extensions["dependencies"] = dependencies
+	}
+}
+
+// addTupleItems adds the prefixItems of a tuple array schema as a draft-07 items array. In draft-07, the schema
+// for the items after the tuple is additionalItems, so the schema's items, if it has any, are moved there.
+func addTupleItems(schema *openapi3.Schema) {
+	prefixItems, ok := schema.Extensions["prefixItems"].([]any)
+	if !ok {
+		return
+	}
+	if _, ok := schema.Extensions["items"]; ok {
+		return
+	}
+
+	if schema.Items != nil {
+		schema.Extensions["additionalItems"] = rawSchema(schema.Items)
+		schema.Items = nil
+	}
+	schema.Extensions["items"] = prefixItems
+}
+
+// tupleItemSchemas returns the schemas in the prefixItems of a tuple array schema, parsed from their raw values.
+// Items that can't be parsed are returned as empty schemas.
+func tupleItemSchemas(schema *openapi3.Schema) []*openapi3.SchemaRef {
+	prefixItems, _ := schema.Extensions["prefixItems"].([]any)
+	items := make([]*openapi3.SchemaRef, 0, len(prefixItems))
+	for _, item := range prefixItems {
+		itemSchema := openapi3.NewSchema()
+		if data, err := json.Marshal(item); err == nil {
+			_ = json.Unmarshal(data, itemSchema)
+		}
+		items = append(items, openapi3.NewSchemaRef("", itemSchema))
+	}
+	return items
+}
+
+// inlineRawRefs replaces the references to component schemas in a raw schema value with the raw component schemas.
+func inlineRawRefs(value any, components openapi3.Schemas, depth int) any {
+	switch v := value.(type) {
+	case map[string]any:
+		if ref, ok := v["$ref"].(string); ok && depth < maxRawRefDepth {
+			if name, ok := strings.CutPrefix(ref, "#/components/schemas/"); ok && components[name] != nil {
+				return inlineRawRefs(rawSchema(components[name]), components, depth+1)
+			}
+		}
+		for key, item := range v {
+			v[key] = inlineRawRefs(item, components, depth)
+		}
+	case []any:
+		for i, item := range v {
+			v[i] = inlineRawRefs(item, components, depth)
+		}
+	}
+	return value
+}
+
+// rawSchema returns the schema as a raw JSON value. Its references are left for inlineRawRefs to inline.
+func rawSchema(r *openapi3.SchemaRef) any {
+	data, err := json.Marshal(r.Value)
+	if err != nil {
+		return map[string]any{}
+	}
+	var raw any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return map[string]any{}
+	}
+	return raw
+}
@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// runShipment runs createShipment from the conditionals spec with a transport that accepts anything,
+// and returns the validation error, if any.
+func runShipment(t *testing.T, args string) error {
+	t.Helper()
+	recordRequests(t)
+
+	_, _, err := Run("createShipment", "testdata/conditionals.yaml", args, RunOptions{})
+	var validationErr *ValidationError
+	if err != nil && !errors.As(err, &validationErr) {
+		t.Fatalf("got error %v, want a validation error", err)
+	}
+	return err
+}
+
+func TestConditionalRequiredFieldsAreEnforced(t *testing.T) {
+	schema, _ := getSchema(t, "createShipment", "testdata/conditionals.yaml")
+	if strings.Contains(schema.Raw, "$ref") {
+		t.Errorf("schema still has a reference: %s", schema.Raw)
+	}
+	body := schema.Get("properties.requestBodyContent")
+	if got := fmt.Sprint(body.Get("then.required").Value()); got != "[address]" {
+		t.Errorf("got then.required %s, want the inlined [address]", got)
+	}
+	if got := fmt.Sprint(body.Get("dependencies.card").Value()); got != "[billingZip]" {
+		t.Errorf("got dependencies.card %s, want [billingZip]", got)
+	}
+
+	tests := []struct {
+		name  string
+		body  string
+		valid bool
+	}{
+		{"then branch without the field", `{"method": "delivery"}`, false},
+		{"then branch with the field", `{"method": "delivery", "address": "1 Main St"}`, true},
+		{"if is false", `{"method": "pickup"}`, true},
+		{"dependentRequired without the field", `{"method": "pickup", "card": "4242"}`, false},
+		{"dependentRequired with the field", `{"method": "pickup", "card": "4242", "billingZip": "12345"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runShipment(t, `{"requestBodyContent": `+tt.body+`}`)
+			if tt.valid && err != nil {
+				t.Errorf("got error %v, want none", err)
+			} else if !tt.valid && err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
+
+func TestTupleParameterIsValidated(t *testing.T) {
+	schema, _ := getSchema(t, "createShipment", "testdata/conditionals.yaml")
+	point := schema.Get("properties.point")
+	if strings.Contains(point.Raw, "$ref") {
+		t.Errorf("tuple schema still has a reference: %s", point.Raw)
+	}
+	if got := fmt.Sprint(point.Get("items.#.type").Value()); got != "[number string]" {
+		t.Errorf("got items types %s, want [number string]", got)
+	}
+	if got := point.Get("additionalItems.type").String(); got != "boolean" {
+		t.Errorf("got additionalItems type %q, want boolean", got)
+	}
+
+	tests := []struct {
+		name  string
+		point string
+		valid bool
+	}{
+		{"tuple", `[1.5, "a"]`, true},
+		{"tuple with additional items", `[1.5, "a", true]`, true},
+		{"wrong type in the tuple", `["a", "a"]`, false},
+		{"wrong type of referenced item", `[1.5, 2]`, false},
+		{"wrong type of additional item", `[1.5, "a", "b"]`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := runShipment(t, `{"point": `+tt.point+`, "requestBodyContent": {"method": "pickup"}}`)
+			if tt.valid && err != nil {
+				t.Errorf("got error %v, want none", err)
+			} else if !tt.valid && err == nil {
+				t.Error("expected a validation error")
+			}
+		})
+	}
+}
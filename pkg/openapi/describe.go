@@ -0,0 +1,205 @@
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// OperationDescription is an overview of an operation, for people working out how to call it.
+type OperationDescription struct {
+	OperationID string   `json:"operationId"`
+	Method      string   `json:"method"`
+	Path        string   `json:"path"`
+	Summary     string   `json:"summary,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Deprecated  bool     `json:"deprecated,omitempty"`
+	// Server is the URL of the server that requests are sent to, with its variables filled in.
+	Server string `json:"server,omitempty"`
+	// ServerVariables are the variables of the server URL, sorted by name.
+	ServerVariables []ServerVariable        `json:"serverVariables,omitempty"`
+	Parameters      []ParameterDescription  `json:"parameters,omitempty"`
+	RequestBody     *RequestBodyDescription `json:"requestBody,omitempty"`
+	// Security lists the alternative sets of security scheme names that can authenticate the request.
+	Security [][]string `json:"security,omitempty"`
+	// ExternalDocs links to more documentation about the operation.
+	ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
+	// DocumentExternalDocs links to more documentation about the whole API.
+	DocumentExternalDocs *ExternalDocs `json:"documentExternalDocs,omitempty"`
+	// Extensions are the operation's x- extensions that were asked for, keyed by their full names.
+	Extensions map[string]any `json:"extensions,omitempty"`
+}
+
+// ExternalDocs is a link to documentation outside of the OpenAPI document.
+type ExternalDocs struct {
+	URL         string `json:"url"`
+	Description string `json:"description,omitempty"`
+}
+
+// ServerVariable is a variable in a server URL.
+type ServerVariable struct {
+	Name string `json:"name"`
+	// Value is the value that is used for the variable.
+	Value       string   `json:"value"`
+	Default     string   `json:"default,omitempty"`
+	Enum        []string `json:"enum,omitempty"`
+	Description string   `json:"description,omitempty"`
+}
+
+// ParameterDescription is an overview of an operation's parameter.
+type ParameterDescription struct {
+	Name string `json:"name"`
+	In   string `json:"in"`
+	// ArgName is the name of the argument that holds the parameter's value.
+	ArgName     string `json:"argName"`
+	Required    bool   `json:"required,omitempty"`
+	Deprecated  bool   `json:"deprecated,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// RequestBodyDescription is an overview of an operation's request body.
+type RequestBodyDescription struct {
+	ContentTypes []string `json:"contentTypes"`
+	Required     bool     `json:"required,omitempty"`
+	Description  string   `json:"description,omitempty"`
+}
+
+// Describe returns an overview of an operation. The ServerVariables and IgnoreCase options are used,
+// and the server variables are checked against their allowed values.
+// Return values in order: OperationDescription, found (bool), error.
+func Describe(operationID, file string, opts SchemaOptions) (OperationDescription, bool, error) {
+	t, err := newLoader(opts.LenientJSON).LoadFromFile(file)
+	if err != nil {
+		return OperationDescription{}, false, &LoadError{File: file, Err: err}
+	}
+
+	if opts.IgnoreCase {
+		if operationID, err = resolveOperationID(t, operationID); err != nil {
+			return OperationDescription{}, false, err
+		}
+	}
+
+	for path, pathItem := range t.Paths.Map() {
+		for method, operation := range pathItem.Operations() {
+			if operation.OperationID != operationID {
+				continue
+			}
+
+			d := OperationDescription{
+				OperationID:          operation.OperationID,
+				Method:               method,
+				Path:                 path,
+				Summary:              operation.Summary,
+				Description:          operation.Description,
+				Tags:                 operation.Tags,
+				Deprecated:           operation.Deprecated,
+				ExternalDocs:         externalDocs(operation.ExternalDocs),
+				DocumentExternalDocs: externalDocs(t.ExternalDocs),
+				Extensions:           selectExtensions(operation.Extensions, opts.Extensions),
+			}
+
+			if servers := operationServers(t, pathItem, operation); len(servers) > 0 {
+				if d.Server, err = parseServer(servers[0], opts.ServerVariables); err != nil {
+					return OperationDescription{}, false, err
+				}
+				if d.ServerVariables, err = describeServerVariables(servers[0], opts.ServerVariables); err != nil {
+					return OperationDescription{}, false, err
+				}
+			}
+
+			if err := checkParametersResolved(pathItem.Parameters, operation.Parameters); err != nil {
+				return OperationDescription{}, false, fmt.Errorf("operation %s: %w", operationID, err)
+			}
+			params := mergeParameters(pathItem.Parameters, operation.Parameters)
+			argNames := parameterArgNames(params)
+			for i, param := range params {
+				d.Parameters = append(d.Parameters, ParameterDescription{
+					Name:        param.Value.Name,
+					In:          param.Value.In,
+					ArgName:     argNames[i],
+					Required:    param.Value.Required,
+					Deprecated:  param.Value.Deprecated,
+					Description: param.Value.Description,
+				})
+			}
+
+			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				body := operation.RequestBody.Value
+				d.RequestBody = &RequestBodyDescription{
+					ContentTypes: sortedKeys(body.Content),
+					Required:     body.Required,
+					Description:  body.Description,
+				}
+			}
+
+			for _, schemes := range parseSecurity(t, operation) {
+				names := make([]string, 0, len(schemes))
+				for _, scheme := range schemes {
+					names = append(names, scheme.Name)
+				}
+				d.Security = append(d.Security, names)
+			}
+
+			return d, true, nil
+		}
+	}
+
+	return OperationDescription{}, false, nil
+}
+
+// externalDocs converts the external documentation of a document or operation. It returns nil if there is none.
+func externalDocs(docs *openapi3.ExternalDocs) *ExternalDocs {
+	if docs == nil || docs.URL == "" {
+		return nil
+	}
+	return &ExternalDocs{URL: docs.URL, Description: docs.Description}
+}
+
+// selectExtensions returns the x- extensions with the names, which may leave out the x- prefix, or all of them for *.
+// It returns nil if none of them are there.
+func selectExtensions(extensions map[string]any, names []string) map[string]any {
+	var result map[string]any
+	for name, value := range extensions {
+		if !strings.HasPrefix(name, "x-") || !slices.ContainsFunc(names, func(n string) bool {
+			return n == "*" || n == name || "x-"+n == name
+		}) {
+			continue
+		}
+		if result == nil {
+			result = map[string]any{}
+		}
+		result[name] = value
+	}
+	return result
+}
+
+// describeServerVariables returns the variables of the server, sorted by name, with the values they get from vars.
+func describeServerVariables(server *openapi3.Server, vars map[string]string) ([]ServerVariable, error) {
+	var result []ServerVariable
+	for name, variable := range server.Variables {
+		if variable == nil {
+			continue
+		}
+
+		value, err := serverVariableValue(name, variable, vars)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, ServerVariable{
+			Name:        name,
+			Value:       value,
+			Default:     variable.Default,
+			Enum:        variable.Enum,
+			Description: variable.Description,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result, nil
+}
@@ -0,0 +1,208 @@
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// SpecDiff lists the differences between the operations of two versions of an OpenAPI document.
+type SpecDiff struct {
+	// Added and Removed are the IDs of the operations that are only in the new or only in the old document.
+	Added   []string          `json:"added"`
+	Removed []string          `json:"removed"`
+	Changed []OperationChange `json:"changed"`
+	// Breaking is the number of changes that can break existing callers, including the removed operations.
+	Breaking int `json:"breaking"`
+}
+
+// OperationChange lists the changes to an operation that is in both documents.
+type OperationChange struct {
+	OperationID string   `json:"operationId"`
+	Changes     []Change `json:"changes"`
+}
+
+// Change is a difference in an operation's request between the two documents.
+type Change struct {
+	Description string `json:"description"`
+	// Breaking is set for changes that can make requests that worked with the old document fail,
+	// like a removed argument, a newly required one, or a changed type.
+	Breaking bool `json:"breaking,omitempty"`
+}
+
+// Diff compares the operations of two OpenAPI documents: which operations were added and removed, and how the
+// method, path, and arguments of the others changed. The arguments are compared the way GetSchema builds them,
+// so parameters and the request body are compared as the properties of one schema, at every level.
+func Diff(oldFile, newFile string, lenientJSON bool) (SpecDiff, error) {
+	oldDoc, err := newLoader(lenientJSON).LoadFromFile(oldFile)
+	if err != nil {
+		return SpecDiff{}, &LoadError{File: oldFile, Err: err}
+	}
+	newDoc, err := newLoader(lenientJSON).LoadFromFile(newFile)
+	if err != nil {
+		return SpecDiff{}, &LoadError{File: newFile, Err: err}
+	}
+
+	oldIDs, newIDs := operationIDs(oldDoc), operationIDs(newDoc)
+	diff := SpecDiff{Added: []string{}, Removed: []string{}, Changed: []OperationChange{}}
+	for _, operationID := range newIDs {
+		if !slices.Contains(oldIDs, operationID) {
+			diff.Added = append(diff.Added, operationID)
+		}
+	}
+	for _, operationID := range oldIDs {
+		if !slices.Contains(newIDs, operationID) {
+			diff.Removed = append(diff.Removed, operationID)
+			diff.Breaking++
+			continue
+		}
+
+		oldArgs, oldInfo, _, err := operationArguments(oldDoc, operationID, SchemaOptions{})
+		if err != nil {
+			return SpecDiff{}, fmt.Errorf("failed to get schema for operation %s in file %s: %w", operationID, oldFile, err)
+		}
+		newArgs, newInfo, _, err := operationArguments(newDoc, operationID, SchemaOptions{})
+		if err != nil {
+			return SpecDiff{}, fmt.Errorf("failed to get schema for operation %s in file %s: %w", operationID, newFile, err)
+		}
+
+		d := schemaDiffer{visited: map[[2]*openapi3.Schema]bool{}}
+		if oldRequest, newRequest := strings.ToUpper(oldInfo.Method)+" "+oldInfo.Path, strings.ToUpper(newInfo.Method)+" "+newInfo.Path; oldRequest != newRequest {
+			d.add(true, "request changed from %s to %s", oldRequest, newRequest)
+		}
+		d.compare("", oldArgs, newArgs)
+		if len(d.changes) == 0 {
+			continue
+		}
+
+		diff.Changed = append(diff.Changed, OperationChange{OperationID: operationID, Changes: d.changes})
+		for _, change := range d.changes {
+			if change.Breaking {
+				diff.Breaking++
+			}
+		}
+	}
+	return diff, nil
+}
+
+// operationIDs returns the IDs of the operations in the document, sorted.
+func operationIDs(t *openapi3.T) []string {
+	var ids []string
+	for _, pathItem := range t.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			if operation.OperationID != "" {
+				ids = append(ids, operation.OperationID)
+			}
+		}
+	}
+	slices.Sort(ids)
+	return slices.Compact(ids)
+}
+
+// schemaDiffer collects the changes between an old and a new schema.
+type schemaDiffer struct {
+	changes []Change
+	// visited are the pairs of schemas that were already compared, so that recursive schemas are compared once.
+	visited map[[2]*openapi3.Schema]bool
+}
+
+func (d *schemaDiffer) add(breaking bool, format string, args ...any) {
+	d.changes = append(d.changes, Change{Description: fmt.Sprintf(format, args...), Breaking: breaking})
+}
+
+// compare adds the changes between the schemas at the path, a dotted path of property names where [] stands
+// for the items of an array. The path is empty for the arguments themselves.
+func (d *schemaDiffer) compare(path string, oldSchema, newSchema *openapi3.Schema) {
+	if oldSchema == nil || newSchema == nil || d.visited[[2]*openapi3.Schema{oldSchema, newSchema}] {
+		return
+	}
+	d.visited[[2]*openapi3.Schema{oldSchema, newSchema}] = true
+
+	what := "argument " + path
+	if path == "" {
+		what = "arguments"
+	}
+
+	if oldType, newType := schemaType(oldSchema), schemaType(newSchema); oldType != newType {
+		d.add(true, "type of %s changed from %s to %s", what, typeName(oldType), typeName(newType))
+		return
+	}
+
+	oldValues, newValues := enumValues(oldSchema), enumValues(newSchema)
+	switch {
+	case len(oldValues) == 0 && len(newValues) > 0:
+		d.add(true, "%s is now limited to %s", what, strings.Join(newValues, ", "))
+	case len(oldValues) > 0 && len(newValues) == 0:
+		d.add(false, "%s is no longer limited to a set of values", what)
+	default:
+		for _, value := range oldValues {
+			if !slices.Contains(newValues, value) {
+				d.add(true, "value %s of %s was removed", value, what)
+			}
+		}
+		for _, value := range newValues {
+			if !slices.Contains(oldValues, value) {
+				d.add(false, "value %s of %s was added", value, what)
+			}
+		}
+	}
+
+	for _, name := range sortedKeys(oldSchema.Properties) {
+		propertyPath := joinSchemaPath(path, name)
+		newProperty := newSchema.Properties[name]
+		if newProperty == nil {
+			d.add(true, "argument %s was removed", propertyPath)
+			continue
+		}
+
+		oldRequired, newRequired := slices.Contains(oldSchema.Required, name), slices.Contains(newSchema.Required, name)
+		switch {
+		case !oldRequired && newRequired:
+			d.add(true, "argument %s is now required", propertyPath)
+		case oldRequired && !newRequired:
+			d.add(false, "argument %s is no longer required", propertyPath)
+		}
+		if oldProperty := oldSchema.Properties[name]; oldProperty != nil {
+			d.compare(propertyPath, oldProperty.Value, newProperty.Value)
+		}
+	}
+	for _, name := range sortedKeys(newSchema.Properties) {
+		if _, ok := oldSchema.Properties[name]; ok {
+			continue
+		}
+		if slices.Contains(newSchema.Required, name) {
+			d.add(true, "required argument %s was added", joinSchemaPath(path, name))
+		} else {
+			d.add(false, "optional argument %s was added", joinSchemaPath(path, name))
+		}
+	}
+
+	if oldSchema.Items != nil && newSchema.Items != nil {
+		d.compare(path+"[]", oldSchema.Items.Value, newSchema.Items.Value)
+	}
+}
+
+func joinSchemaPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
+
+func typeName(t string) string {
+	if t == "" {
+		return "any"
+	}
+	return t
+}
+
+// enumValues returns the enumerated values of the schema, formatted for a change description.
+func enumValues(schema *openapi3.Schema) []string {
+	values := make([]string, 0, len(schema.Enum))
+	for _, value := range schema.Enum {
+		values = append(values, fmt.Sprintf("%q", fmt.Sprint(value)))
+	}
+	return values
+}
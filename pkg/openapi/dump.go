@@ -0,0 +1,234 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/invopop/yaml"
+)
+
+type DumpOptions struct {
+	// KeepRefs leaves the $ref values in the document instead of replacing them with what they point to.
+	KeepRefs bool
+	// YAML outputs the document as YAML instead of JSON.
+	YAML bool
+	// LenientJSON allows comments and trailing commas in JSON documents.
+	LenientJSON bool
+}
+
+// Dump loads an OpenAPI document, resolving its internal and external references, and returns it
+// as indented JSON or as YAML. Unless KeepRefs is set, every $ref is replaced with its target, except
+// where a schema refers back to itself, which is left as a $ref.
+func Dump(file string, opts DumpOptions) (string, error) {
+	t, err := newLoader(opts.LenientJSON).LoadFromFile(file)
+	if err != nil {
+		return "", &LoadError{File: file, Err: err}
+	}
+
+	if !opts.KeepRefs {
+		newDereferencer().document(t)
+	}
+
+	out, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OpenAPI document: %w", err)
+	}
+	if opts.YAML {
+		if out, err = yaml.JSONToYAML(out); err != nil {
+			return "", fmt.Errorf("failed to convert OpenAPI document to YAML: %w", err)
+		}
+		return string(out), nil
+	}
+	return string(out) + "\n", nil
+}
+
+// dereferencer clears the Ref of every reference in a document, so that it is marshaled as its value.
+type dereferencer struct {
+	// visiting holds the schemas that are being walked, to find the references that form a cycle.
+	visiting map[*openapi3.Schema]bool
+	// done holds the schemas that have already been walked, so that shared schemas are only walked once.
+	done map[*openapi3.Schema]bool
+}
+
+func newDereferencer() *dereferencer {
+	return &dereferencer{
+		visiting: map[*openapi3.Schema]bool{},
+		done:     map[*openapi3.Schema]bool{},
+	}
+}
+
+func (d *dereferencer) document(t *openapi3.T) {
+	if t.Paths != nil {
+		for _, pathItem := range t.Paths.Map() {
+			d.pathItem(pathItem)
+		}
+	}
+
+	if t.Components == nil {
+		return
+	}
+	for _, s := range t.Components.Schemas {
+		d.schema(s)
+	}
+	d.parameters(t.Components.Parameters)
+	d.headers(t.Components.Headers)
+	for _, r := range t.Components.RequestBodies {
+		d.requestBody(r)
+	}
+	for _, r := range t.Components.Responses {
+		d.response(r)
+	}
+	for _, s := range t.Components.SecuritySchemes {
+		s.Ref = ""
+	}
+	d.examples(t.Components.Examples)
+	d.links(t.Components.Links)
+	d.callbacks(t.Components.Callbacks)
+}
+
+func (d *dereferencer) pathItem(pathItem *openapi3.PathItem) {
+	if pathItem == nil {
+		return
+	}
+	pathItem.Ref = ""
+	d.parameterList(pathItem.Parameters)
+	for _, operation := range pathItem.Operations() {
+		d.parameterList(operation.Parameters)
+		d.requestBody(operation.RequestBody)
+		if operation.Responses != nil {
+			for _, r := range operation.Responses.Map() {
+				d.response(r)
+			}
+		}
+		d.callbacks(operation.Callbacks)
+	}
+}
+
+func (d *dereferencer) schema(s *openapi3.SchemaRef) {
+	if s == nil || s.Value == nil {
+		return
+	}
+	if d.visiting[s.Value] {
+		// Keep the reference that closes the cycle, or the document could not be marshaled.
+		return
+	}
+	s.Ref = ""
+	if d.done[s.Value] {
+		return
+	}
+
+	d.visiting[s.Value] = true
+	defer func() {
+		delete(d.visiting, s.Value)
+		d.done[s.Value] = true
+	}()
+
+	v := s.Value
+	for _, p := range v.Properties {
+		d.schema(p)
+	}
+	d.schema(v.Items)
+	d.schema(v.AdditionalProperties.Schema)
+	d.schema(v.Not)
+	for _, list := range []openapi3.SchemaRefs{v.AllOf, v.AnyOf, v.OneOf} {
+		for _, sub := range list {
+			d.schema(sub)
+		}
+	}
+}
+
+func (d *dereferencer) parameterList(params openapi3.Parameters) {
+	for _, p := range params {
+		d.parameter(p)
+	}
+}
+
+func (d *dereferencer) parameters(params openapi3.ParametersMap) {
+	for _, p := range params {
+		d.parameter(p)
+	}
+}
+
+func (d *dereferencer) parameter(p *openapi3.ParameterRef) {
+	if p == nil {
+		return
+	}
+	p.Ref = ""
+	if p.Value != nil {
+		d.schema(p.Value.Schema)
+		d.examples(p.Value.Examples)
+		d.content(p.Value.Content)
+	}
+}
+
+func (d *dereferencer) headers(headers openapi3.Headers) {
+	for _, h := range headers {
+		h.Ref = ""
+		if h.Value != nil {
+			d.schema(h.Value.Schema)
+			d.examples(h.Value.Examples)
+			d.content(h.Value.Content)
+		}
+	}
+}
+
+func (d *dereferencer) requestBody(r *openapi3.RequestBodyRef) {
+	if r == nil {
+		return
+	}
+	r.Ref = ""
+	if r.Value != nil {
+		d.content(r.Value.Content)
+	}
+}
+
+func (d *dereferencer) response(r *openapi3.ResponseRef) {
+	if r == nil {
+		return
+	}
+	r.Ref = ""
+	if r.Value != nil {
+		d.headers(r.Value.Headers)
+		d.content(r.Value.Content)
+		d.links(r.Value.Links)
+	}
+}
+
+func (d *dereferencer) content(content openapi3.Content) {
+	for _, mediaType := range content {
+		if mediaType == nil {
+			continue
+		}
+		d.schema(mediaType.Schema)
+		d.examples(mediaType.Examples)
+		for _, encoding := range mediaType.Encoding {
+			if encoding != nil {
+				d.headers(encoding.Headers)
+			}
+		}
+	}
+}
+
+func (d *dereferencer) examples(examples openapi3.Examples) {
+	for _, e := range examples {
+		e.Ref = ""
+	}
+}
+
+func (d *dereferencer) links(links openapi3.Links) {
+	for _, l := range links {
+		l.Ref = ""
+	}
+}
+
+func (d *dereferencer) callbacks(callbacks openapi3.Callbacks) {
+	for _, c := range callbacks {
+		c.Ref = ""
+		if c.Value != nil {
+			for _, pathItem := range c.Value.Map() {
+				d.pathItem(pathItem)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// envReferenceRegexp matches ${NAME} references to environment variables, and the $${ escape for a literal ${.
+var envReferenceRegexp = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)}`)
+
+// expandEnvArgs replaces the ${NAME} references in the string values of the arguments with the values
+// of the environment variables. Only values are expanded, not property names, so a variable's value
+// can't change the structure of the arguments. $${ is left as a literal ${. It is an error if a
+// referenced variable isn't set, so that a missing secret isn't silently sent as an empty string.
+func expandEnvArgs(args string) (string, error) {
+	var value any
+	decoder := json.NewDecoder(strings.NewReader(args))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	expanded, err := expandEnvValue(value)
+	if err != nil {
+		return "", err
+	}
+
+	result, err := json.Marshal(expanded)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	return string(result), nil
+}
+
+func expandEnvValue(value any) (any, error) {
+	switch v := value.(type) {
+	case string:
+		return expandEnvString(v)
+	case map[string]any:
+		for key, item := range v {
+			expanded, err := expandEnvValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = expanded
+		}
+	case []any:
+		for i, item := range v {
+			expanded, err := expandEnvValue(item)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = expanded
+		}
+	}
+	return value, nil
+}
+
+func expandEnvString(s string) (string, error) {
+	var missing []string
+	result := envReferenceRegexp.ReplaceAllStringFunc(s, func(match string) string {
+		if match == "$${" {
+			return "${"
+		}
+		name := match[2 : len(match)-1]
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			missing = append(missing, name)
+		}
+		return value
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("environment variable %s referenced in the arguments is not set", strings.Join(missing, ", "))
+	}
+	return result, nil
+}
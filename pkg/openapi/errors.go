@@ -0,0 +1,43 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ValidationError is returned by Run when the arguments don't match the operation's schema.
+type ValidationError struct {
+	OperationID string
+	Errors      []string
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("invalid arguments for operation %s: %s", e.OperationID, strings.Join(e.Errors, "; "))
+}
+
+// LoadError is returned when an OpenAPI file can't be read or parsed.
+type LoadError struct {
+	File string
+	Err  error
+}
+
+func (e *LoadError) Error() string {
+	return fmt.Sprintf("failed to load OpenAPI file %s: %v", e.File, e.Err)
+}
+
+func (e *LoadError) Unwrap() error {
+	return e.Err
+}
+
+// RequestError is returned by Run when the request can't be sent or the response can't be read.
+type RequestError struct {
+	Err error
+}
+
+func (e *RequestError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *RequestError) Unwrap() error {
+	return e.Err
+}
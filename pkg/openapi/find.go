@@ -0,0 +1,111 @@
+package openapi
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// OperationMatch is an operation that matches a search for operations.
+type OperationMatch struct {
+	OperationID string `json:"operationId"`
+	Summary     string `json:"summary,omitempty"`
+	// Score is how well the operation matches, from 0 to 1: the similarity of the search word that matches worst.
+	Score float64 `json:"score"`
+}
+
+// descriptionWeight scales the similarity of the words in an operation's description, which has many words that
+// aren't about what the operation does, compared to the words of its ID, summary, and tags.
+const descriptionWeight = 0.9
+
+// FindOperations returns the operations in the file that match the query, best first. Every word of the query
+// must be similar to a word of the operation's ID, summary, tags, or description, so an operation matches with
+// the lowest similarity of any of the query's words, which must be at least the threshold. Similarity is 1 minus
+// the edit distance between the words over the length of the longer one, so typos and plurals still match.
+// Webhooks aren't included, since they can't be run.
+func FindOperations(file, query string, threshold float64, lenientJSON bool) ([]OperationMatch, error) {
+	list, err := List(file, ListOptions{LenientJSON: lenientJSON})
+	if err != nil {
+		return nil, err
+	}
+
+	queryWords := searchWords(query)
+	if len(queryWords) == 0 {
+		return nil, nil
+	}
+
+	var matches []OperationMatch
+	for operationID, operation := range list.Operations {
+		if operation.Webhook != "" {
+			continue
+		}
+
+		words := searchWords(operationID + " " + operation.Summary + " " + strings.Join(operation.Tags, " "))
+		descriptionWords := searchWords(operation.Description)
+		score := 1.0
+		for _, queryWord := range queryWords {
+			var best float64
+			for _, word := range words {
+				best = max(best, wordSimilarity(queryWord, word))
+			}
+			for _, word := range descriptionWords {
+				best = max(best, descriptionWeight*wordSimilarity(queryWord, word))
+			}
+			score = min(score, best)
+		}
+		if score >= threshold {
+			matches = append(matches, OperationMatch{OperationID: operationID, Summary: operation.Summary, Score: score})
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].OperationID < matches[j].OperationID
+	})
+	return matches, nil
+}
+
+var (
+	wordBoundaryRegexp = regexp.MustCompile(`([a-z0-9])([A-Z])`)
+	nonWordRegexp      = regexp.MustCompile(`[^a-z0-9]+`)
+)
+
+// searchWords splits text into lowercase words, including the words of camelCase and snake_case identifiers.
+func searchWords(text string) []string {
+	text = strings.ToLower(wordBoundaryRegexp.ReplaceAllString(text, "$1 $2"))
+	return strings.Fields(nonWordRegexp.ReplaceAllString(text, " "))
+}
+
+// wordSimilarity returns the similarity of two words from 0 to 1, based on their edit distance, counting
+// a transposition of two adjacent letters as one edit.
+func wordSimilarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+	x, y := []rune(a), []rune(b)
+
+	// d[i][j] is the distance between the first i runes of x and the first j runes of y.
+	d := make([][]int, len(x)+1)
+	for i := range d {
+		d[i] = make([]int, len(y)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(x); i++ {
+		for j := 1; j <= len(y); j++ {
+			cost := 1
+			if x[i-1] == y[j-1] {
+				cost = 0
+			}
+			d[i][j] = min(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && x[i-1] == y[j-2] && x[i-2] == y[j-1] {
+				d[i][j] = min(d[i][j], d[i-2][j-2]+1)
+			}
+		}
+	}
+	return 1 - float64(d[len(x)][len(y)])/float64(max(len(x), len(y)))
+}
@@ -0,0 +1,71 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// FillFixedArgs fills in each argument that can only have one value, because its schema has a const
+// or an enum with a single value, and that is missing from the args. Properties of nested objects
+// and of the objects in arrays are filled in the same way.
+func FillFixedArgs(schemaJSON, args string) (string, error) {
+	if args == "" {
+		args = "{}"
+	}
+
+	var value any
+	decoder := json.NewDecoder(strings.NewReader(args))
+	decoder.UseNumber()
+	if err := decoder.Decode(&value); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	if !fillFixedValues(gjson.Parse(schemaJSON), value) {
+		return args, nil
+	}
+
+	result, err := json.Marshal(value)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	return string(result), nil
+}
+
+// fillFixedValues sets the missing properties of the objects in the value that have a fixed value
+// in the schema, and returns whether any were set.
+func fillFixedValues(schema gjson.Result, value any) bool {
+	var filled bool
+	switch v := value.(type) {
+	case map[string]any:
+		schema.Get("properties").ForEach(func(name, property gjson.Result) bool {
+			if _, ok := v[name.String()]; !ok {
+				if fixed, ok := fixedValue(property); ok {
+					v[name.String()] = fixed
+					filled = true
+				}
+			}
+			filled = fillFixedValues(property, v[name.String()]) || filled
+			return true
+		})
+	case []any:
+		items := schema.Get("items")
+		for _, item := range v {
+			filled = fillFixedValues(items, item) || filled
+		}
+	}
+	return filled
+}
+
+// fixedValue returns the only value allowed by the schema, if it has one.
+func fixedValue(schema gjson.Result) (any, bool) {
+	if c := schema.Get("const"); c.Exists() {
+		return json.RawMessage(c.Raw), true
+	}
+	if enum := schema.Get("enum").Array(); len(enum) == 1 {
+		return json.RawMessage(enum[0].Raw), true
+	}
+	return nil, false
+}
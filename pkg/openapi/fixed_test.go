@@ -0,0 +1,44 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRunFillsFixedBodyProperties(t *testing.T) {
+	tests := []struct {
+		name, args, body string
+	}{
+		{
+			"const and single-value enum",
+			`{"requestBodyContent": {"name": "Rex"}}`,
+			`{"name":"Rex","type":"dog","version":2}`,
+		},
+		{
+			"given values are kept",
+			`{"requestBodyContent": {"name": "Rex", "type": "dog", "version": 2}}`,
+			`{"name":"Rex","type":"dog","version":2}`,
+		},
+		{
+			"nested object",
+			`{"requestBodyContent": {"name": "Rex", "owner": {"name": "Sam"}}}`,
+			`{"name":"Rex","owner":{"kind":"person","name":"Sam"},"type":"dog","version":2}`,
+		},
+		{
+			"objects in an array",
+			`{"requestBodyContent": {"name": "Rex", "toys": [{"name": "ball"}, {"name": "rope"}]}}`,
+			`{"name":"Rex","toys":[{"kind":"toy","name":"ball"},{"kind":"toy","name":"rope"}],"type":"dog","version":2}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := recordRequests(t)
+			if _, _, err := Run("createPet", "testdata/fixed.yaml", tt.args, RunOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if body := strings.TrimSpace(rt.bodies[0]); body != tt.body {
+				t.Errorf("got body %s, want %s", body, tt.body)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package openapi
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/tidwall/gjson"
+)
+
+// numericFormatRanges are the smallest and largest values allowed by the OpenAPI numeric formats.
+// The JSON schema validator doesn't know these formats, so they are checked separately.
+var numericFormatRanges = map[string][2]*big.Rat{
+	"int32":  {new(big.Rat).SetInt64(math.MinInt32), new(big.Rat).SetInt64(math.MaxInt32)},
+	"int64":  {new(big.Rat).SetInt64(math.MinInt64), new(big.Rat).SetInt64(math.MaxInt64)},
+	"float":  {new(big.Rat).SetFloat64(-math.MaxFloat32), new(big.Rat).SetFloat64(math.MaxFloat32)},
+	"double": {new(big.Rat).SetFloat64(-math.MaxFloat64), new(big.Rat).SetFloat64(math.MaxFloat64)},
+}
+
+// stringFormatCheckers check the OpenAPI string formats that the JSON schema validator doesn't know.
+// Formats like date-time, email, and uuid are already checked by the validator.
+var stringFormatCheckers = map[string]func(string) bool{
+	"byte": func(s string) bool {
+		_, err := base64.StdEncoding.DecodeString(s)
+		return err == nil
+	},
+}
+
+// checkFormats returns an error for each value in the args that doesn't fit the format declared
+// for it in the schema, for the formats that the JSON schema validator doesn't check.
+func checkFormats(schemaJSON, args string) []string {
+	var errs []string
+	checkValueFormats(gjson.Parse(schemaJSON), gjson.Parse(args), "", &errs)
+	return errs
+}
+
+func checkValueFormats(schema, value gjson.Result, path string, errs *[]string) {
+	switch {
+	case value.IsObject():
+		schema.Get("properties").ForEach(func(name, property gjson.Result) bool {
+			if v := value.Get(gjson.Escape(name.String())); v.Exists() {
+				checkValueFormats(property, v, joinArgPath(path, name.String()), errs)
+			}
+			return true
+		})
+	case value.IsArray():
+		items := schema.Get("items")
+		for i, item := range value.Array() {
+			checkValueFormats(items, item, joinArgPath(path, fmt.Sprint(i)), errs)
+		}
+	case value.Type == gjson.String:
+		format := schema.Get("format").String()
+		if check, ok := stringFormatCheckers[format]; ok && !check(value.String()) {
+			*errs = append(*errs, fmt.Sprintf("%s: Does not match format '%s'", path, format))
+		}
+	case value.Type == gjson.Number:
+		format := schema.Get("format").String()
+		limits, ok := numericFormatRanges[format]
+		if !ok {
+			return
+		}
+		n, ok := new(big.Rat).SetString(value.Raw)
+		if ok && (n.Cmp(limits[0]) < 0 || n.Cmp(limits[1]) > 0) {
+			*errs = append(*errs, fmt.Sprintf("%s: %s is out of range for format %s", path, value.Raw, format))
+		}
+	}
+}
+
+// joinArgPath adds a property name or array index to the dotted path of an argument.
+func joinArgPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,45 @@
+package openapi
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestRunValidatesStringFormats(t *testing.T) {
+	tests := []struct {
+		name, args, err string
+	}{
+		{"valid values", `{"id": "3fa85f64-5717-4562-b3fc-2c963f66afa6", "since": "2024-05-01T12:00:00Z", "day": "2024-05-01", "owner": "a@example.com"}`, ""},
+		{"malformed uuid", `{"id": "not-a-uuid"}`, "id: Does not match format 'uuid'"},
+		{"malformed date-time", `{"since": "2024-05-01 12:00"}`, "since: Does not match format 'date-time'"},
+		{"malformed date", `{"day": "05/01/2024"}`, "day: Does not match format 'date'"},
+		{"malformed email", `{"owner": "nobody"}`, "owner: Does not match format 'email'"},
+	}
+	for _, tt := range tests {
+		for _, strict := range []bool{false, true} {
+			name := tt.name
+			if strict {
+				name += " with strict formats"
+			}
+			t.Run(name, func(t *testing.T) {
+				recordRequests(t)
+				_, _, err := Run("listEvents", "testdata/formats.yaml", tt.args, RunOptions{StrictFormats: strict})
+				if tt.err == "" {
+					if err != nil {
+						t.Fatal(err)
+					}
+					return
+				}
+
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("got error %v, want a validation error", err)
+				}
+				if !strings.Contains(err.Error(), tt.err) {
+					t.Errorf("got error %q, want it to contain %q", err, tt.err)
+				}
+			})
+		}
+	}
+}
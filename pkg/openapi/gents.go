@@ -0,0 +1,227 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// GenerateTypeScript generates TypeScript declarations for the arguments of an operation and, if response is set,
+// for the body of its success response. An object of the arguments type can be passed to run as JSON.
+// lenientJSON allows comments and trailing commas in JSON documents.
+// Return values in order: TypeScript source (string), found (bool), error.
+func GenerateTypeScript(operationID, file string, response, lenientJSON bool) (string, bool, error) {
+	t, err := newLoader(lenientJSON).LoadFromFile(file)
+	if err != nil {
+		return "", false, &LoadError{File: file, Err: err}
+	}
+
+	arguments, _, found, err := operationArguments(t, operationID, SchemaOptions{})
+	if err != nil || !found {
+		return "", found, err
+	}
+
+	var (
+		g      = newTSTypeGenerator()
+		prefix = goName(operationID)
+	)
+	if body := arguments.Properties["requestBodyContent"]; body != nil {
+		// Give the body a shorter name than the one derived from the argument name.
+		g.typeExpr(prefix+"Body", body.Value)
+	}
+	g.namedType(prefix+"Args", arguments)
+
+	if response {
+		if operation := findOperation(t, operationID); operation != nil {
+			if _, resp := mockResponse(operation); resp != nil && len(resp.Content) > 0 {
+				if _, content := preferredContent(resp.Content); content != nil && content.Schema != nil {
+					if typ := g.typeExpr(prefix+"Response", content.Schema.Value); !g.used[typ] {
+						// Name the response type even when it isn't an object, like an array of objects.
+						fmt.Fprintf(&g.decls, "export type %s = %s;\n\n", g.unique(prefix+"Response"), typ)
+					}
+				}
+			}
+		}
+	}
+
+	src := "// Code generated by openapi-cli gen-ts. DO NOT EDIT.\n\n" + g.decls.String()
+	return strings.TrimRight(src, "\n") + "\n", true, nil
+}
+
+// tsTypeGenerator writes TypeScript declarations for schemas.
+type tsTypeGenerator struct {
+	decls strings.Builder
+	// names are the names of the interfaces declared for schemas, so that each schema is declared once
+	// and recursive schemas refer to their own interface.
+	names map[*openapi3.Schema]string
+	// used are the names that are already taken by declarations.
+	used map[string]bool
+}
+
+func newTSTypeGenerator() *tsTypeGenerator {
+	return &tsTypeGenerator{
+		names: map[*openapi3.Schema]string{},
+		used:  map[string]bool{},
+	}
+}
+
+// typeExpr returns the TypeScript type for the schema. Objects with properties get an interface, which is
+// declared the first time the schema is seen, using the name. Enums become unions of their values.
+func (g *tsTypeGenerator) typeExpr(name string, schema *openapi3.Schema) string {
+	if schema == nil {
+		return "unknown"
+	}
+	typ := g.baseTypeExpr(name, schema)
+	if schema.Nullable && typ != "unknown" {
+		typ += " | null"
+	}
+	return typ
+}
+
+func (g *tsTypeGenerator) baseTypeExpr(name string, schema *openapi3.Schema) string {
+	if name, ok := g.names[schema]; ok {
+		return name
+	}
+	if len(schema.Enum) > 0 {
+		values := make([]string, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			data, err := json.Marshal(value)
+			if err != nil {
+				return "unknown"
+			}
+			if !slices.Contains(values, string(data)) {
+				values = append(values, string(data))
+			}
+		}
+		return strings.Join(values, " | ")
+	}
+	if len(schema.Properties) > 0 {
+		return g.namedType(name, schema)
+	}
+
+	switch {
+	case len(schema.OneOf) > 0:
+		return g.compositeExpr(name+"Option", schema.OneOf, " | ")
+	case len(schema.AnyOf) > 0:
+		return g.compositeExpr(name+"Option", schema.AnyOf, " | ")
+	case len(schema.AllOf) > 0:
+		return g.compositeExpr(name+"Part", schema.AllOf, " & ")
+	}
+
+	switch schemaType(schema) {
+	case "string":
+		return "string"
+	case "integer", "number":
+		return "number"
+	case "boolean":
+		return "boolean"
+	case "array":
+		if schema.Items == nil {
+			return "unknown[]"
+		}
+		item := g.typeExpr(name+"Item", schema.Items.Value)
+		if strings.ContainsAny(item, "|&") {
+			item = "(" + item + ")"
+		}
+		return item + "[]"
+	case "object":
+		if additional := schema.AdditionalProperties.Schema; additional != nil {
+			return "Record<string, " + g.typeExpr(name+"Value", additional.Value) + ">"
+		}
+		return "Record<string, unknown>"
+	}
+	return "unknown"
+}
+
+// compositeExpr returns the types of the subschemas joined with the operator, numbering their names.
+func (g *tsTypeGenerator) compositeExpr(name string, refs openapi3.SchemaRefs, operator string) string {
+	types := make([]string, 0, len(refs))
+	for i, ref := range refs {
+		var schema *openapi3.Schema
+		if ref != nil {
+			schema = ref.Value
+		}
+		typ := g.typeExpr(fmt.Sprintf("%s%d", name, i+1), schema)
+		if strings.ContainsAny(typ, "|&") {
+			typ = "(" + typ + ")"
+		}
+		if !slices.Contains(types, typ) {
+			types = append(types, typ)
+		}
+	}
+	return strings.Join(types, operator)
+}
+
+// namedType declares an interface with the name for the object schema and returns the name, which is made unique if it is taken.
+func (g *tsTypeGenerator) namedType(name string, schema *openapi3.Schema) string {
+	name = g.unique(name)
+	g.names[schema] = name
+
+	var decl strings.Builder
+	writeJSDoc(&decl, "", schema.Description)
+	fmt.Fprintf(&decl, "export interface %s {\n", name)
+	for _, property := range sortedKeys(schema.Properties) {
+		var propertySchema *openapi3.Schema
+		if ref := schema.Properties[property]; ref != nil {
+			propertySchema = ref.Value
+		}
+		propertyType := g.typeExpr(name+goName(property), propertySchema)
+
+		optional := "?"
+		if slices.Contains(schema.Required, property) {
+			optional = ""
+		}
+
+		if propertySchema != nil {
+			writeJSDoc(&decl, "  ", propertySchema.Description)
+		}
+		fmt.Fprintf(&decl, "  %s%s: %s;\n", tsPropertyName(property), optional, propertyType)
+	}
+	decl.WriteString("}\n\n")
+
+	g.decls.WriteString(decl.String())
+	return name
+}
+
+// unique returns the name, with a number added if it is already taken, and marks it as taken.
+func (g *tsTypeGenerator) unique(name string) string {
+	result := name
+	for i := 2; g.used[result]; i++ {
+		result = fmt.Sprintf("%s%d", name, i)
+	}
+	g.used[result] = true
+	return result
+}
+
+var tsIdentifierRegexp = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// tsPropertyName returns the property name as it is written in an interface, quoted if it isn't an identifier.
+func tsPropertyName(name string) string {
+	if tsIdentifierRegexp.MatchString(name) {
+		return name
+	}
+	data, _ := json.Marshal(name)
+	return string(data)
+}
+
+// writeJSDoc writes the description as a JSDoc comment with the indent, if there is a description.
+func writeJSDoc(b *strings.Builder, indent, description string) {
+	description = strings.TrimSpace(strings.ReplaceAll(description, "*/", "*\\/"))
+	if description == "" {
+		return
+	}
+	lines := strings.Split(description, "\n")
+	if len(lines) == 1 {
+		b.WriteString(indent + "/** " + lines[0] + " */\n")
+		return
+	}
+	b.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+}
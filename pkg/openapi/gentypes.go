@@ -0,0 +1,274 @@
+package openapi
+
+import (
+	"fmt"
+	"go/format"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// GenerateTypes generates Go type definitions for the arguments of an operation and for the body of its
+// success response. The arguments type can be marshaled to JSON to get the arguments for Run.
+// lenientJSON allows comments and trailing commas in JSON documents.
+// Return values in order: Go source (string), found (bool), error.
+func GenerateTypes(operationID, file, packageName string, lenientJSON bool) (string, bool, error) {
+	t, err := newLoader(lenientJSON).LoadFromFile(file)
+	if err != nil {
+		return "", false, &LoadError{File: file, Err: err}
+	}
+
+	arguments, _, found, err := operationArguments(t, operationID, SchemaOptions{})
+	if err != nil || !found {
+		return "", found, err
+	}
+
+	var (
+		g      = newTypeGenerator()
+		prefix = goName(operationID)
+	)
+	if body := arguments.Properties["requestBodyContent"]; body != nil {
+		// Give the body a shorter name than the one derived from the argument name.
+		g.typeExpr(prefix+"Body", body.Value)
+	}
+	g.namedType(prefix+"Args", arguments)
+
+	if operation := findOperation(t, operationID); operation != nil {
+		if _, response := mockResponse(operation); response != nil && len(response.Content) > 0 {
+			if _, content := preferredContent(response.Content); content != nil && content.Schema != nil {
+				if typ := g.typeExpr(prefix+"Response", content.Schema.Value); !g.used[typ] {
+					// Name the response type even when it isn't an object, like an array of objects.
+					fmt.Fprintf(&g.decls, "type %s %s\n\n", g.unique(prefix+"Response"), typ)
+				}
+			}
+		}
+	}
+
+	src := fmt.Sprintf("// Code generated by openapi-cli gen-types. DO NOT EDIT.\n\npackage %s\n\n%s", packageName, g.decls.String())
+	formatted, err := format.Source([]byte(src))
+	if err != nil {
+		return "", false, fmt.Errorf("failed to format generated types: %w", err)
+	}
+	return string(formatted), true, nil
+}
+
+// findOperation returns the operation with the ID in the document, or nil if there isn't one.
+func findOperation(t *openapi3.T, operationID string) *openapi3.Operation {
+	for _, pathItem := range t.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			if operation.OperationID == operationID {
+				return operation
+			}
+		}
+	}
+	return nil
+}
+
+// typeGenerator writes Go type declarations for schemas.
+type typeGenerator struct {
+	decls strings.Builder
+	// names are the names of the types declared for schemas, so that each schema is declared once
+	// and recursive schemas refer to their own type.
+	names map[*openapi3.Schema]string
+	// used are the names that are already taken by types and constants.
+	used map[string]bool
+	// structs are the names of the struct types.
+	structs map[string]bool
+}
+
+func newTypeGenerator() *typeGenerator {
+	return &typeGenerator{
+		names:   map[*openapi3.Schema]string{},
+		used:    map[string]bool{},
+		structs: map[string]bool{},
+	}
+}
+
+// typeExpr returns the Go type for the schema. Objects with properties and string enums get a named type,
+// which is declared the first time the schema is seen, using the name.
+func (g *typeGenerator) typeExpr(name string, schema *openapi3.Schema) string {
+	if schema == nil {
+		return "any"
+	}
+	if name, ok := g.names[schema]; ok {
+		return name
+	}
+	if len(schema.Properties) > 0 || isStringEnum(schema) {
+		return g.namedType(name, schema)
+	}
+
+	switch schemaType(schema) {
+	case "string":
+		return "string"
+	case "integer":
+		if schema.Format == "int32" {
+			return "int32"
+		}
+		return "int64"
+	case "number":
+		if schema.Format == "float" {
+			return "float32"
+		}
+		return "float64"
+	case "boolean":
+		return "bool"
+	case "array":
+		if schema.Items == nil {
+			return "[]any"
+		}
+		return "[]" + g.typeExpr(name+"Item", schema.Items.Value)
+	case "object":
+		if additional := schema.AdditionalProperties.Schema; additional != nil {
+			return "map[string]" + g.typeExpr(name+"Value", additional.Value)
+		}
+		return "map[string]any"
+	}
+	return "any"
+}
+
+// namedType declares a type with the name for the schema and returns the name, which is made unique if it is taken.
+// String enums become a string type with a constant for each value, and anything else becomes a struct.
+func (g *typeGenerator) namedType(name string, schema *openapi3.Schema) string {
+	name = g.unique(name)
+	g.names[schema] = name
+
+	var decl strings.Builder
+	writeDocComment(&decl, "", schema.Description)
+	if isStringEnum(schema) {
+		fmt.Fprintf(&decl, "type %s string\n\nconst (\n", name)
+		for i, value := range schema.Enum {
+			suffix := goName(fmt.Sprint(value))
+			if suffix == "Value" {
+				suffix = fmt.Sprintf("Value%d", i)
+			}
+			fmt.Fprintf(&decl, "\t%s %s = %q\n", g.unique(name+suffix), name, value)
+		}
+		decl.WriteString(")\n\n")
+	} else {
+		g.structs[name] = true
+
+		fmt.Fprintf(&decl, "type %s struct {\n", name)
+		fieldNames := map[string]bool{}
+		for _, property := range sortedKeys(schema.Properties) {
+			propertySchema := schema.Properties[property].Value
+			fieldName := goName(property)
+			for i := 2; fieldNames[fieldName]; i++ {
+				fieldName = fmt.Sprintf("%s%d", goName(property), i)
+			}
+			fieldNames[fieldName] = true
+			fieldType := g.typeExpr(name+fieldName, propertySchema)
+
+			tag := property
+			if !slices.Contains(schema.Required, property) {
+				tag += ",omitempty"
+				if g.structs[fieldType] {
+					fieldType = "*" + fieldType
+				}
+			}
+
+			if propertySchema != nil {
+				writeDocComment(&decl, "\t", propertySchema.Description)
+			}
+			fmt.Fprintf(&decl, "\t%s %s `json:%q`\n", fieldName, fieldType, tag)
+		}
+		decl.WriteString("}\n\n")
+	}
+
+	g.decls.WriteString(decl.String())
+	return name
+}
+
+// unique returns the name, with a number added if it is already taken, and marks it as taken.
+func (g *typeGenerator) unique(name string) string {
+	result := name
+	for i := 2; g.used[result]; i++ {
+		result = fmt.Sprintf("%s%d", name, i)
+	}
+	g.used[result] = true
+	return result
+}
+
+// schemaType returns the type of the schema, or an empty string if it has none or more than one.
+func schemaType(schema *openapi3.Schema) string {
+	if types := schema.Type.Slice(); len(types) == 1 {
+		return types[0]
+	}
+	if len(schema.Properties) > 0 {
+		return "object"
+	}
+	return ""
+}
+
+// isStringEnum returns whether the schema is a string with enumerated values.
+func isStringEnum(schema *openapi3.Schema) bool {
+	if schemaType(schema) != "string" || len(schema.Enum) == 0 {
+		return false
+	}
+	for _, value := range schema.Enum {
+		if _, ok := value.(string); !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// writeDocComment writes the description as a Go comment with the indent, if there is a description.
+func writeDocComment(b *strings.Builder, indent, description string) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return
+	}
+	for _, line := range strings.Split(description, "\n") {
+		b.WriteString(strings.TrimRight(indent+"// "+line, " ") + "\n")
+	}
+}
+
+// goInitialisms are words that are written in all capitals in Go names.
+var goInitialisms = []string{"API", "HTML", "HTTP", "HTTPS", "ID", "IP", "JSON", "SQL", "TLS", "UI", "URI", "URL", "UUID", "XML"}
+
+// goName converts a name from the document, like user_id or userId, to an exported Go name like UserID.
+func goName(name string) string {
+	var (
+		b     strings.Builder
+		word  []rune
+		words []string
+	)
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && len(word) > 0 && !unicode.IsUpper(word[len(word)-1]):
+			flush()
+			word = append(word, r)
+		default:
+			word = append(word, r)
+		}
+	}
+	flush()
+
+	for _, w := range words {
+		if upper := strings.ToUpper(w); slices.Contains(goInitialisms, upper) {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(w)
+		b.WriteString(string(unicode.ToUpper(runes[0])) + string(runes[1:]))
+	}
+
+	result := b.String()
+	switch {
+	case result == "":
+		return "Value"
+	case unicode.IsDigit([]rune(result)[0]):
+		return "N" + result
+	}
+	return result
+}
@@ -1,6 +1,7 @@
 package openapi
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"slices"
@@ -10,26 +11,217 @@ import (
 )
 
 type Parameter struct {
-	Name, Style string
-	Explode     *bool
+	Name, In, Style string
+	// Explode is the parameter's explode setting as written in the document. It is nil when the document
+	// doesn't set it, which is different from false: the default then depends on the style (see explode).
+	Explode         *bool
+	AllowReserved   bool
+	AllowEmptyValue bool
+	Deprecated      bool
+	// ArgName is the name of the property holding this parameter's value in the arguments.
+	// It is usually the same as Name, but is qualified by location when parameters
+	// in different locations share a name.
+	ArgName string
+}
+
+// style returns the serialization style of the parameter, using the default style
+// for the parameter's location if it doesn't have one.
+func (p Parameter) style() string {
+	if p.Style != "" {
+		return p.Style
+	}
+	if p.In == "query" || p.In == "cookie" {
+		return "form"
+	}
+	return "simple"
+}
+
+// explode returns whether the parameter's value should be exploded. If the parameter doesn't say,
+// the default is true for the form style, and false for all other styles.
+func (p Parameter) explode() bool {
+	if p.Explode != nil {
+		return *p.Explode
+	}
+	return p.style() == "form"
 }
 
 type OperationInfo struct {
+	// OperationID is the ID of the operation as it appears in the document.
+	OperationID                           string
 	Server, Path, Method, BodyContentMIME string
-	// TODO - security infos
+	Deprecated                            bool
+	// The operation's parameters, by location.
 	QueryParams, PathParams, HeaderParams, CookieParams []Parameter
+	// Security lists the alternative sets of security schemes that can authenticate the request.
+	// All the schemes in a set are used together. It is empty if the operation doesn't require authentication.
+	Security [][]SecurityScheme
+	// Examples are the named examples from the request body and parameters, keyed by example name.
+	Examples map[string]Example
+	// BodyEncoding describes how individual properties of a multipart request body are encoded, keyed by property name.
+	BodyEncoding map[string]Encoding
+	// ResponseContentTypes are the media types of the operation's success responses, sorted by name.
+	ResponseContentTypes []string
+	// Servers are the URLs of all the operation's servers in the order they are declared, with their variables
+	// not filled in. Server is the one that is used, which is chosen with SchemaOptions.ServerIndex.
+	Servers []string
+	// ConflictingParameters are the names of the parameters that are defined at both the path and the
+	// operation level with different schemas. The operation's definition is the one that is used.
+	ConflictingParameters []string
+}
+
+// Encoding is the encoding of a single multipart request body property.
+type Encoding struct {
+	ContentType string
+	// Headers are extra headers to send with the part.
+	Headers map[string]string
+}
+
+// SecurityScheme is a security scheme from the document's components.
+type SecurityScheme struct {
+	// Name is the name of the scheme in the document's components.
+	Name string
+	// Type is apiKey, http, oauth2, openIdConnect, or mutualTLS.
+	Type string
+	// Scheme is the HTTP authentication scheme (e.g. bearer or basic) for the http type.
+	Scheme string
+	// In and ParamName are the location and name of the key for the apiKey type.
+	In, ParamName string
+}
+
+// hasParameters returns whether the operation has any path, query, header, or cookie parameters.
+func (o OperationInfo) hasParameters() bool {
+	return len(o.PathParams)+len(o.QueryParams)+len(o.HeaderParams)+len(o.CookieParams) > 0
+}
+
+// Example is a named example for an operation's arguments.
+// Examples with the same name on the request body and on parameters are combined into one.
+type Example struct {
+	Summary     string `json:"summary,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Args holds the example's values, keyed by argument name.
+	Args map[string]any `json:"args"`
 }
 
 var supportedMIMETypes = []string{"application/json", "application/x-www-form-urlencoded", "multipart/form-data"}
 
+// SchemaOptions are optional settings that control how GetSchema builds the schema.
+type SchemaOptions struct {
+	// KeepRefs keeps references to component schemas instead of inlining them,
+	// and adds the referenced component schemas to the output under "components".
+	// Read-only properties are left in the component schemas as they are defined.
+	KeepRefs bool
+	// MergeAllOf merges allOf subschemas into the schemas that contain them, combining their
+	// properties and required lists. It has no effect when KeepRefs is set.
+	MergeAllOf bool
+	// BodyIsRoot uses the request body schema as the schema for the arguments, instead of nesting
+	// it under "requestBodyContent", for operations that have a request body and no parameters.
+	BodyIsRoot bool
+	// ServerVariables overrides the default values of the variables in the operation's server URL, keyed by variable name.
+	ServerVariables map[string]string
+	// ServerIndex is the index of the server to use in the operation's list of servers. It defaults to the first one.
+	ServerIndex int
+	// BaseURL replaces the operation's server, for when none of the servers in the document is the right one.
+	// It is used as it is, so ServerVariables and ServerIndex have no effect.
+	BaseURL string
+	// RequiredOnly removes the optional properties from the schema, at every level, so that only the
+	// minimum set of arguments needed to call the operation is left. Referenced schemas are left as they are.
+	RequiredOnly bool
+	// IgnoreCase matches the operation ID case-insensitively. An exact match is preferred,
+	// and it is an error if several operations match only when ignoring case.
+	IgnoreCase bool
+	// LenientJSON allows comments and trailing commas in JSON documents.
+	LenientJSON bool
+	// Extensions are the x- extensions of the operation that Describe includes, or * for all of them.
+	// The x- prefix can be left out of the names.
+	Extensions []string
+}
+
 // GetSchema returns the JSONSchema and OperationInfo for a particular OpenAPI operation.
 // Return values in order: JSONSchema (string), OperationInfo, found (bool), error.
-func GetSchema(operationID, file string) (string, OperationInfo, bool, error) {
-	loader := openapi3.NewLoader()
-	t, err := loader.LoadFromFile(file)
+func GetSchema(operationID, file string, opts SchemaOptions) (string, OperationInfo, bool, error) {
+	t, err := newLoader(opts.LenientJSON).LoadFromFile(file)
+	if err != nil {
+		return "", OperationInfo{}, false, &LoadError{File: file, Err: err}
+	}
+
+	output, info, found, err := argumentsOutput(t, operationID, opts)
+	if err != nil || !found {
+		return "", OperationInfo{}, false, err
+	}
+
+	argumentsJSON, err := json.MarshalIndent(output, "", "    ")
 	if err != nil {
 		return "", OperationInfo{}, false, err
 	}
+	return string(argumentsJSON), info, true, nil
+}
+
+// GetSchemas returns the JSONSchema of each of the operations in the file, keyed by operation ID,
+// loading the file only once. If operationIDs is empty, the schemas of all the operations are returned.
+// Operations that aren't in the file are left out.
+func GetSchemas(operationIDs []string, file string, opts SchemaOptions) (map[string]json.RawMessage, error) {
+	t, err := newLoader(opts.LenientJSON).LoadFromFile(file)
+	if err != nil {
+		return nil, &LoadError{File: file, Err: err}
+	}
+
+	if len(operationIDs) == 0 {
+		for _, pathItem := range t.Paths.Map() {
+			for _, operation := range pathItem.Operations() {
+				if operation.OperationID != "" {
+					operationIDs = append(operationIDs, operation.OperationID)
+				}
+			}
+		}
+	}
+
+	schemas := make(map[string]json.RawMessage, len(operationIDs))
+	for _, operationID := range operationIDs {
+		output, info, found, err := argumentsOutput(t, operationID, opts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get schema for operation %s: %w", operationID, err)
+		}
+		if !found {
+			continue
+		}
+
+		schema, err := json.Marshal(output)
+		if err != nil {
+			return nil, err
+		}
+		schemas[info.OperationID] = schema
+	}
+	return schemas, nil
+}
+
+// argumentsOutput returns the arguments schema of an operation in the document, in the form that GetSchema outputs it.
+func argumentsOutput(t *openapi3.T, operationID string, opts SchemaOptions) (any, OperationInfo, bool, error) {
+	arguments, info, found, err := operationArguments(t, operationID, opts)
+	if err != nil || !found {
+		return nil, OperationInfo{}, false, err
+	}
+
+	if opts.RequiredOnly {
+		removeOptionalProperties(arguments, map[*openapi3.Schema]bool{})
+	}
+
+	if opts.KeepRefs && t.Components != nil && len(t.Components.Schemas) > 0 {
+		// Add the component schemas so that the references can be resolved from the root of the output.
+		m, err := arguments.MarshalYAML()
+		if err != nil {
+			return nil, OperationInfo{}, false, err
+		}
+		withComponents := m.(map[string]any)
+		withComponents["components"] = map[string]any{"schemas": t.Components.Schemas}
+		return withComponents, info, true, nil
+	}
+	return arguments, info, true, nil
+}
+
+// operationArguments builds the schema for the arguments of an operation in the document,
+// along with the information needed to make the operation's request.
+func operationArguments(t *openapi3.T, operationID string, opts SchemaOptions) (*openapi3.Schema, OperationInfo, bool, error) {
+	var err error
 
 	// We basically want to extract all the information that we need for the HTTP request,
 	// like we do in GPTScript.
@@ -39,67 +231,91 @@ func GetSchema(operationID, file string) (string, OperationInfo, bool, error) {
 		Required:   []string{},
 	}
 
-	info := OperationInfo{}
-
-	// Determine the default server.
-	// TODO - take in a default host parameter? Like the source where the OpenAPI doc was downloaded from?
-	var defaultServer string
-	if len(t.Servers) > 0 {
-		defaultServer, err = parseServer(t.Servers[0])
+	if opts.IgnoreCase {
+		operationID, err = resolveOperationID(t, operationID)
 		if err != nil {
-			return "", OperationInfo{}, false, err
+			return nil, OperationInfo{}, false, err
 		}
 	}
 
-	for path, pathItem := range t.Paths.Map() {
-		// Handle path-level server override, if one exists.
-		pathServer := defaultServer
-		if pathItem.Servers != nil && len(pathItem.Servers) > 0 {
-			pathServer, err = parseServer(pathItem.Servers[0])
-			if err != nil {
-				return "", OperationInfo{}, false, err
-			}
-		}
+	info := OperationInfo{}
 
+	for path, pathItem := range t.Paths.Map() {
 		for method, operation := range pathItem.Operations() {
 			if operation.OperationID == operationID {
-				// Handle operation-level server override, if one exists.
-				operationServer := pathServer
-				if operation.Servers != nil && len(*operation.Servers) > 0 {
-					operationServer, err = parseServer((*operation.Servers)[0])
+				// Determine the server.
+				// TODO - take in a default host parameter? Like the source where the OpenAPI doc was downloaded from?
+				servers := operationServers(t, pathItem, operation)
+				for _, server := range servers {
+					info.Servers = append(info.Servers, server.URL)
+				}
+				switch {
+				case opts.BaseURL != "":
+					info.Server = opts.BaseURL
+				case opts.ServerIndex < 0 || opts.ServerIndex > 0 && opts.ServerIndex >= len(servers):
+					return nil, OperationInfo{}, false, fmt.Errorf("server index %d is out of range for operation %s, which has %d server(s)", opts.ServerIndex, operationID, len(servers))
+				case len(servers) > 0:
+					info.Server, err = parseServer(servers[opts.ServerIndex], opts.ServerVariables)
 					if err != nil {
-						return "", OperationInfo{}, false, err
+						return nil, OperationInfo{}, false, err
 					}
 				}
 
-				info.Server = operationServer
+				info.OperationID = operation.OperationID
 				info.Path = path
 				info.Method = method
+				info.Deprecated = operation.Deprecated
+				info.Security = parseSecurity(t, operation)
+				info.ResponseContentTypes = successContentTypes(operation)
 
 				// We found our operation. Now we need to process it and build the arguments.
 				// Handle query, path, header, and cookie parameters first.
-				for _, param := range append(operation.Parameters, pathItem.Parameters...) {
-					removeRefs(param.Value.Schema)
-					arg := param.Value.Schema.Value
+				// The loader resolves references to components.parameters, but their values are checked
+				// in case the document wasn't fully resolved.
+				if err := checkParametersResolved(pathItem.Parameters, operation.Parameters); err != nil {
+					return nil, OperationInfo{}, false, fmt.Errorf("operation %s: %w", operationID, err)
+				}
+				info.ConflictingParameters = conflictingParameters(pathItem.Parameters, operation.Parameters)
+				params := mergeParameters(pathItem.Parameters, operation.Parameters)
+				argNames := parameterArgNames(params)
+				for i, param := range params {
+					param.Value.Schema = parameterSchema(param.Value)
+					if opts.KeepRefs {
+						arguments.Properties[argNames[i]] = param.Value.Schema
+					} else {
+						schema := copySchemaRef(param.Value.Schema)
+						removeRefs(schema, componentSchemas(t))
+						if opts.MergeAllOf {
+							schema = mergeAllOf(schema)
+						}
+						arg := schema.Value
 
-					if arg.Description == "" {
-						arg.Description = param.Value.Description
-					}
+						if arg.Description == "" {
+							arg.Description = param.Value.Description
+						}
 
-					// Store the arg
-					arguments.Properties[param.Value.Name] = &openapi3.SchemaRef{Value: arg}
+						// Store the arg
+						arguments.Properties[argNames[i]] = &openapi3.SchemaRef{Value: arg}
+					}
 
 					// Check whether it is required
 					if param.Value.Required {
-						arguments.Required = append(arguments.Required, param.Value.Name)
+						arguments.Required = append(arguments.Required, argNames[i])
 					}
 
 					// Save the parameter to the correct set of params.
 					p := Parameter{
-						Name:    param.Value.Name,
-						Style:   param.Value.Style,
-						Explode: param.Value.Explode,
+						Name:            param.Value.Name,
+						In:              param.Value.In,
+						Style:           param.Value.Style,
+						Explode:         param.Value.Explode,
+						AllowReserved:   param.Value.AllowReserved,
+						AllowEmptyValue: param.Value.AllowEmptyValue,
+						Deprecated:      param.Value.Deprecated,
+						ArgName:         argNames[i],
 					}
+					addExamples(&info, argNames[i], param.Value.Examples)
+
 					switch param.Value.In {
 					case "query":
 						info.QueryParams = append(info.QueryParams, p)
@@ -112,64 +328,370 @@ func GetSchema(operationID, file string) (string, OperationInfo, bool, error) {
 					}
 				}
 
-				// Next, handle the request body, if one exists.
-				if operation.RequestBody != nil {
+				// Next, handle the request body, if one exists. The loader resolves references to
+				// components.requestBodies, so the body is the same whether it is inline or not.
+				if operation.RequestBody != nil && operation.RequestBody.Value != nil {
 					for mime, content := range operation.RequestBody.Value.Content {
 						// Each MIME type needs to be handled individually, so we keep a list of the ones we support.
-						if !slices.Contains(supportedMIMETypes, mime) {
+						if !slices.Contains(supportedMIMETypes, mime) || content == nil {
 							continue
 						}
 						info.BodyContentMIME = mime
+						addExamples(&info, "requestBodyContent", content.Examples)
+						info.BodyEncoding = parseEncoding(content.Encoding)
+
+						// Unfortunately, the request body doesn't contain any good descriptor for it,
+						// so we just use "requestBodyContent" as the name of the arg.
+						arguments.Required = append(arguments.Required, "requestBodyContent")
+						if content.Schema == nil || content.Schema.Value == nil {
+							// A media type without a schema takes any value.
+							content.Schema = &openapi3.SchemaRef{Value: &openapi3.Schema{}}
+						}
+						if opts.KeepRefs {
+							arguments.Properties["requestBodyContent"] = content.Schema
+							break
+						}
 
-						removeRefs(content.Schema)
+						// The schema is copied, since inlining its references and removing its read-only properties
+						// would otherwise change the component schemas that other operations share.
+						schema := copySchemaRef(content.Schema)
+						removeRefs(schema, componentSchemas(t))
+						if opts.MergeAllOf {
+							schema = mergeAllOf(schema)
+						}
 
-						arg := content.Schema.Value
+						arg := schema.Value
 						if arg.Description == "" {
-							arg.Description = content.Schema.Value.Description
+							arg.Description = operation.RequestBody.Value.Description
 						}
 
 						// Read Only cannot be sent in the request body, so we remove it
-						for key, property := range arg.Properties {
-							if property.Value.ReadOnly {
-								delete(arg.Properties, key)
-							}
-						}
+						removeReadOnlyProperties(arg)
 
-						// Unfortunately, the request body doesn't contain any good descriptor for it,
-						// so we just use "requestBodyContent" as the name of the arg.
 						arguments.Properties["requestBodyContent"] = &openapi3.SchemaRef{Value: arg}
-						arguments.Required = append(arguments.Required, "requestBodyContent")
 						break
 					}
 
 					if info.BodyContentMIME == "" {
-						return "", OperationInfo{}, false, fmt.Errorf("no supported MIME type found for request body in operation %s", operationID)
+						return nil, OperationInfo{}, false, fmt.Errorf("no supported MIME type found for request body in operation %s", operationID)
 					}
 				}
 
-				argumentsJSON, err := json.MarshalIndent(arguments, "", "    ")
-				if err != nil {
-					return "", OperationInfo{}, false, err
+				if body := arguments.Properties["requestBodyContent"]; opts.BodyIsRoot && body != nil && !info.hasParameters() {
+					return body.Value, info, true, nil
 				}
-				return string(argumentsJSON), info, true, nil
+				return arguments, info, true, nil
 			}
 		}
 	}
 
-	return "", OperationInfo{}, false, nil
+	return nil, OperationInfo{}, false, nil
+}
+
+// resolveOperationID returns the ID of the operation in the document that matches operationID, ignoring case.
+// If no operation matches, operationID is returned as is.
+func resolveOperationID(t *openapi3.T, operationID string) (string, error) {
+	var candidates []string
+	for _, pathItem := range t.Paths.Map() {
+		for _, operation := range pathItem.Operations() {
+			if operation.OperationID == operationID {
+				return operationID, nil
+			}
+			if strings.EqualFold(operation.OperationID, operationID) {
+				candidates = append(candidates, operation.OperationID)
+			}
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return operationID, nil
+	case 1:
+		return candidates[0], nil
+	default:
+		slices.Sort(candidates)
+		return "", fmt.Errorf("operation %s matches multiple operations when ignoring case: %s", operationID, strings.Join(candidates, ", "))
+	}
+}
+
+// removeReadOnlyProperties removes the read-only properties of an object schema, or of the items
+// of an array schema, since they can't be sent in a request body. The properties are also removed
+// from the required lists, so that the schema doesn't require properties that it no longer allows.
+// A property can be read-only in one allOf subschema and required in another, or by the schema itself,
+// so the subschemas are treated as a single object.
+func removeReadOnlyProperties(schema *openapi3.Schema) {
+	if schema.Items != nil && schema.Items.Value != nil {
+		removeReadOnlyProperties(schema.Items.Value)
+	}
+
+	readOnly := map[string]bool{}
+	collectReadOnlyProperties(schema, readOnly)
+	if len(readOnly) > 0 {
+		stripProperties(schema, readOnly)
+	}
 }
 
-func parseServer(server *openapi3.Server) (string, error) {
+// collectReadOnlyProperties adds the names of the read-only properties of the schema and its allOf subschemas to names.
+func collectReadOnlyProperties(schema *openapi3.Schema, names map[string]bool) {
+	for key, property := range schema.Properties {
+		if property != nil && property.Value != nil && property.Value.ReadOnly {
+			names[key] = true
+		}
+	}
+	for _, sub := range schema.AllOf {
+		if sub != nil && sub.Value != nil {
+			collectReadOnlyProperties(sub.Value, names)
+		}
+	}
+}
+
+// stripProperties removes the named properties from the properties and required list of the schema and its allOf subschemas.
+func stripProperties(schema *openapi3.Schema, names map[string]bool) {
+	for key := range schema.Properties {
+		if names[key] {
+			delete(schema.Properties, key)
+		}
+	}
+	schema.Required = slices.DeleteFunc(schema.Required, func(name string) bool {
+		return names[name]
+	})
+	for _, sub := range schema.AllOf {
+		if sub != nil && sub.Value != nil {
+			stripProperties(sub.Value, names)
+		}
+	}
+}
+
+// mergeParameters combines path-level and operation-level parameters.
+// A parameter is identified by its name and location, and operation-level parameters
+// override path-level parameters with the same identity.
+func mergeParameters(pathParams, operationParams openapi3.Parameters) openapi3.Parameters {
+	type key struct{ name, in string }
+
+	var (
+		result = make(openapi3.Parameters, 0, len(pathParams)+len(operationParams))
+		seen   = make(map[key]struct{}, len(operationParams))
+	)
+	for _, param := range operationParams {
+		seen[key{param.Value.Name, param.Value.In}] = struct{}{}
+		result = append(result, param)
+	}
+	for _, param := range pathParams {
+		if _, ok := seen[key{param.Value.Name, param.Value.In}]; ok {
+			continue
+		}
+		result = append(result, param)
+	}
+	return result
+}
+
+// checkParametersResolved returns an error for the first parameter whose reference wasn't resolved,
+// since its name and location aren't known.
+func checkParametersResolved(lists ...openapi3.Parameters) error {
+	for _, params := range lists {
+		for _, param := range params {
+			if param == nil {
+				return fmt.Errorf("parameter is empty")
+			} else if param.Value == nil {
+				return fmt.Errorf("parameter %s isn't resolved", param.Ref)
+			}
+		}
+	}
+	return nil
+}
+
+// parameterSchema returns the schema of the parameter. A parameter can give its schema in a media type
+// of its content instead, and a parameter with neither takes any value.
+func parameterSchema(param *openapi3.Parameter) *openapi3.SchemaRef {
+	if param.Schema != nil && param.Schema.Value != nil {
+		return param.Schema
+	}
+	for _, mediaType := range sortedKeys(param.Content) {
+		if content := param.Content[mediaType]; content != nil && content.Schema != nil && content.Schema.Value != nil {
+			return content.Schema
+		}
+	}
+	return &openapi3.SchemaRef{Value: &openapi3.Schema{}}
+}
+
+// conflictingParameters returns the names of the path-level parameters that are overridden by an operation-level
+// parameter with the same name and location, but with a different schema. Schemas are compared by their
+// resolved values, so two references to equal schemas don't conflict.
+func conflictingParameters(pathParams, operationParams openapi3.Parameters) []string {
+	var conflicts []string
+	for _, pathParam := range pathParams {
+		operationParam := operationParams.GetByInAndName(pathParam.Value.In, pathParam.Value.Name)
+		if operationParam == nil {
+			continue
+		}
+		pathSchema, _ := json.Marshal(schemaValue(pathParam.Value.Schema))
+		operationSchema, _ := json.Marshal(schemaValue(operationParam.Schema))
+		if !bytes.Equal(pathSchema, operationSchema) {
+			conflicts = append(conflicts, pathParam.Value.Name)
+		}
+	}
+	return conflicts
+}
+
+// schemaValue returns the schema a reference points to, or nil if there is none.
+func schemaValue(s *openapi3.SchemaRef) *openapi3.Schema {
+	if s == nil {
+		return nil
+	}
+	return s.Value
+}
+
+// parameterArgNames returns the argument name to use for each parameter.
+// Parameters whose name is shared with a parameter in a different location are
+// qualified by their location (e.g. "path_userId" and "query_userId") so that neither is lost.
+func parameterArgNames(params openapi3.Parameters) []string {
+	locations := make(map[string]int, len(params))
+	for _, param := range params {
+		locations[param.Value.Name]++
+	}
+
+	argNames := make([]string, len(params))
+	for i, param := range params {
+		if locations[param.Value.Name] > 1 {
+			argNames[i] = param.Value.In + "_" + param.Value.Name
+		} else {
+			argNames[i] = param.Value.Name
+		}
+	}
+	return argNames
+}
+
+// addExamples adds the value of each example to the operation's examples under the given argument name.
+func addExamples(info *OperationInfo, argName string, examples openapi3.Examples) {
+	for name, example := range examples {
+		if example == nil || example.Value == nil || example.Value.Value == nil {
+			continue
+		}
+
+		if info.Examples == nil {
+			info.Examples = map[string]Example{}
+		}
+
+		e, ok := info.Examples[name]
+		if !ok {
+			e = Example{Args: map[string]any{}}
+		}
+		if e.Summary == "" {
+			e.Summary = example.Value.Summary
+		}
+		if e.Description == "" {
+			e.Description = example.Value.Description
+		}
+		e.Args[argName] = example.Value.Value
+		info.Examples[name] = e
+	}
+}
+
+// parseEncoding converts the encoding object of a request body media type.
+// Header values are taken from each header's example or default, since the spec only describes them.
+func parseEncoding(encoding map[string]*openapi3.Encoding) map[string]Encoding {
+	if len(encoding) == 0 {
+		return nil
+	}
+
+	result := make(map[string]Encoding, len(encoding))
+	for name, enc := range encoding {
+		if enc == nil {
+			continue
+		}
+
+		e := Encoding{
+			// The content type can be a comma-separated list of options, so we use the first one.
+			ContentType: strings.TrimSpace(strings.Split(enc.ContentType, ",")[0]),
+			Headers:     map[string]string{},
+		}
+		for headerName, header := range enc.Headers {
+			if header == nil || header.Value == nil {
+				continue
+			}
+
+			var value any
+			if header.Value.Example != nil {
+				value = header.Value.Example
+			} else if header.Value.Schema != nil && header.Value.Schema.Value != nil {
+				value = header.Value.Schema.Value.Default
+			}
+			if value != nil {
+				e.Headers[headerName] = fmt.Sprint(value)
+			}
+		}
+		result[name] = e
+	}
+	return result
+}
+
+// parseSecurity returns the security requirements of the operation, which override the document's
+// requirements if they are set. Requirements that reference unknown schemes are skipped.
+func parseSecurity(t *openapi3.T, operation *openapi3.Operation) [][]SecurityScheme {
+	requirements := t.Security
+	if operation.Security != nil {
+		requirements = *operation.Security
+	}
+
+	var result [][]SecurityScheme
+	for _, requirement := range requirements {
+		names := make([]string, 0, len(requirement))
+		for name := range requirement {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+
+		schemes := make([]SecurityScheme, 0, len(names))
+		for _, name := range names {
+			if t.Components == nil || t.Components.SecuritySchemes[name] == nil || t.Components.SecuritySchemes[name].Value == nil {
+				schemes = nil
+				break
+			}
+
+			scheme := t.Components.SecuritySchemes[name].Value
+			schemes = append(schemes, SecurityScheme{
+				Name:      name,
+				Type:      scheme.Type,
+				Scheme:    strings.ToLower(scheme.Scheme),
+				In:        scheme.In,
+				ParamName: scheme.Name,
+			})
+		}
+		if len(schemes) > 0 {
+			result = append(result, schemes)
+		}
+	}
+	return result
+}
+
+// operationServers returns the servers for an operation. Operation-level servers override path-level servers,
+// which override the document's servers.
+func operationServers(t *openapi3.T, pathItem *openapi3.PathItem, operation *openapi3.Operation) openapi3.Servers {
+	servers := t.Servers
+	if len(pathItem.Servers) > 0 {
+		servers = pathItem.Servers
+	}
+	if operation.Servers != nil && len(*operation.Servers) > 0 {
+		servers = *operation.Servers
+	}
+	return servers
+}
+
+// parseServer returns the URL of the server with its variables substituted. The values of the variables
+// are taken from vars if they are set there, and otherwise from their defaults.
+func parseServer(server *openapi3.Server, vars map[string]string) (string, error) {
 	s := server.URL
 	for name, variable := range server.Variables {
 		if variable == nil {
 			continue
 		}
 
-		if variable.Default != "" {
-			s = strings.Replace(s, "{"+name+"}", variable.Default, 1)
-		} else if len(variable.Enum) > 0 {
-			s = strings.Replace(s, "{"+name+"}", variable.Enum[0], 1)
+		value, err := serverVariableValue(name, variable, vars)
+		if err != nil {
+			return "", err
+		}
+		if value != "" {
+			s = strings.Replace(s, "{"+name+"}", value, 1)
 		}
 	}
 
@@ -179,27 +701,215 @@ func parseServer(server *openapi3.Server) (string, error) {
 	return s, nil
 }
 
-func removeRefs(r *openapi3.SchemaRef) {
+// serverVariableValue returns the value of a server variable: the value from vars if it is set there,
+// and otherwise the variable's default, or its first allowed value if it has no default.
+// Values from vars must be one of the variable's allowed values, if it has any.
+func serverVariableValue(name string, variable *openapi3.ServerVariable, vars map[string]string) (string, error) {
+	if value, ok := vars[name]; ok {
+		if len(variable.Enum) > 0 && !slices.Contains(variable.Enum, value) {
+			return "", fmt.Errorf("invalid value %q for server variable %s (must be one of: %s)", value, name, strings.Join(variable.Enum, ", "))
+		}
+		return value, nil
+	}
+	if variable.Default != "" {
+		return variable.Default, nil
+	}
+	if len(variable.Enum) > 0 {
+		return variable.Enum[0], nil
+	}
+	return "", nil
+}
+
+// mergeAllOf returns a copy of the schema in which the allOf subschemas are merged into the schema itself,
+// at every level of the schema. Properties and required lists are combined, and the type and description
+// of a subschema are only used when the schema doesn't have its own. The original schema is not modified.
+func mergeAllOf(r *openapi3.SchemaRef) *openapi3.SchemaRef {
+	if r == nil || r.Value == nil {
+		return r
+	}
+
+	merged := *r.Value
+	merged.AllOf = nil
+	merged.Required = slices.Clone(r.Value.Required)
+	merged.Properties = make(openapi3.Schemas, len(r.Value.Properties))
+	for name, property := range r.Value.Properties {
+		merged.Properties[name] = mergeAllOf(property)
+	}
+
+	for _, sub := range r.Value.AllOf {
+		sub = mergeAllOf(sub)
+		if sub == nil || sub.Value == nil {
+			continue
+		}
+
+		for name, property := range sub.Value.Properties {
+			if _, ok := merged.Properties[name]; !ok {
+				merged.Properties[name] = property
+			}
+		}
+		for _, required := range sub.Value.Required {
+			if !slices.Contains(merged.Required, required) {
+				merged.Required = append(merged.Required, required)
+			}
+		}
+		if merged.Type == nil || len(*merged.Type) == 0 {
+			merged.Type = sub.Value.Type
+		}
+		if merged.Description == "" {
+			merged.Description = sub.Value.Description
+		}
+	}
+
+	if len(merged.Properties) == 0 {
+		merged.Properties = nil
+	}
+
+	merged.Items = mergeAllOf(r.Value.Items)
+	merged.Not = mergeAllOf(r.Value.Not)
+	merged.OneOf = mergeAllOfEach(r.Value.OneOf)
+	merged.AnyOf = mergeAllOfEach(r.Value.AnyOf)
+
+	return &openapi3.SchemaRef{Ref: r.Ref, Value: &merged}
+}
+
+func mergeAllOfEach(refs openapi3.SchemaRefs) openapi3.SchemaRefs {
+	if refs == nil {
+		return nil
+	}
+
+	result := make(openapi3.SchemaRefs, len(refs))
+	for i, r := range refs {
+		result[i] = mergeAllOf(r)
+	}
+	return result
+}
+
+// removeOptionalProperties removes the properties of the schema, and of its inline property and
+// item schemas, that aren't in the required list of the schema they belong to.
+func removeOptionalProperties(schema *openapi3.Schema, seen map[*openapi3.Schema]bool) {
+	if schema == nil || seen[schema] {
+		return
+	}
+	seen[schema] = true
+
+	for name, property := range schema.Properties {
+		if !slices.Contains(schema.Required, name) {
+			delete(schema.Properties, name)
+			continue
+		}
+		if property.Ref == "" {
+			removeOptionalProperties(property.Value, seen)
+		}
+	}
+	if schema.Items != nil && schema.Items.Ref == "" {
+		removeOptionalProperties(schema.Items.Value, seen)
+	}
+}
+
+// copySchemaRef returns a deep copy of the schema, with its own subschemas, required lists, and extensions.
+// A schema that is reached more than once, like a recursive one, is copied once.
+func copySchemaRef(r *openapi3.SchemaRef) *openapi3.SchemaRef {
+	return copySchemaRefMemo(r, map[*openapi3.Schema]*openapi3.Schema{})
+}
+
+func copySchemaRefMemo(r *openapi3.SchemaRef, copies map[*openapi3.Schema]*openapi3.Schema) *openapi3.SchemaRef {
 	if r == nil {
+		return nil
+	}
+	return &openapi3.SchemaRef{Ref: r.Ref, Value: copySchema(r.Value, copies)}
+}
+
+func copySchema(schema *openapi3.Schema, copies map[*openapi3.Schema]*openapi3.Schema) *openapi3.Schema {
+	if schema == nil {
+		return nil
+	}
+	if c, ok := copies[schema]; ok {
+		return c
+	}
+	c := new(openapi3.Schema)
+	*c = *schema
+	copies[schema] = c
+
+	c.Required = slices.Clone(schema.Required)
+	if schema.Extensions != nil {
+		c.Extensions = copyRawValue(schema.Extensions).(map[string]any)
+	}
+	c.OneOf = copySchemaRefs(schema.OneOf, copies)
+	c.AnyOf = copySchemaRefs(schema.AnyOf, copies)
+	c.AllOf = copySchemaRefs(schema.AllOf, copies)
+	c.Not = copySchemaRefMemo(schema.Not, copies)
+	c.Items = copySchemaRefMemo(schema.Items, copies)
+	c.AdditionalProperties.Schema = copySchemaRefMemo(schema.AdditionalProperties.Schema, copies)
+	if schema.Properties != nil {
+		c.Properties = make(openapi3.Schemas, len(schema.Properties))
+		for name, property := range schema.Properties {
+			c.Properties[name] = copySchemaRefMemo(property, copies)
+		}
+	}
+	return c
+}
+
+func copySchemaRefs(refs openapi3.SchemaRefs, copies map[*openapi3.Schema]*openapi3.Schema) openapi3.SchemaRefs {
+	if refs == nil {
+		return nil
+	}
+	result := make(openapi3.SchemaRefs, len(refs))
+	for i, r := range refs {
+		result[i] = copySchemaRefMemo(r, copies)
+	}
+	return result
+}
+
+// copyRawValue returns a deep copy of a raw JSON value, like the value of a schema extension.
+func copyRawValue(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		result := make(map[string]any, len(v))
+		for key, item := range v {
+			result[key] = copyRawValue(item)
+		}
+		return result
+	case []any:
+		result := make([]any, len(v))
+		for i, item := range v {
+			result[i] = copyRawValue(item)
+		}
+		return result
+	}
+	return value
+}
+
+// removeRefs inlines the references to component schemas in the schema, including the ones inside conditional keywords.
+// References that lead back to a schema that contains them are kept, so that recursive schemas stay finite.
+func removeRefs(r *openapi3.SchemaRef, components openapi3.Schemas) {
+	removeRefsVisiting(r, components, map[*openapi3.Schema]bool{})
+}
+
+func removeRefsVisiting(r *openapi3.SchemaRef, components openapi3.Schemas, visiting map[*openapi3.Schema]bool) {
+	if r == nil || r.Value == nil || visiting[r.Value] {
 		return
 	}
+	visiting[r.Value] = true
+	defer delete(visiting, r.Value)
 
 	r.Ref = ""
 	r.Value.Discriminator = nil // Discriminators are not very useful and can junk up the schema.
 
 	for i := range r.Value.OneOf {
-		removeRefs(r.Value.OneOf[i])
+		removeRefsVisiting(r.Value.OneOf[i], components, visiting)
 	}
 	for i := range r.Value.AnyOf {
-		removeRefs(r.Value.AnyOf[i])
+		removeRefsVisiting(r.Value.AnyOf[i], components, visiting)
 	}
 	for i := range r.Value.AllOf {
-		removeRefs(r.Value.AllOf[i])
+		removeRefsVisiting(r.Value.AllOf[i], components, visiting)
 	}
-	removeRefs(r.Value.Not)
-	removeRefs(r.Value.Items)
+	removeRefsVisiting(r.Value.Not, components, visiting)
+	removeRefsVisiting(r.Value.Items, components, visiting)
 
 	for i := range r.Value.Properties {
-		removeRefs(r.Value.Properties[i])
+		removeRefsVisiting(r.Value.Properties[i], components, visiting)
 	}
+
+	removeConditionalRefs(r.Value, components)
 }
@@ -0,0 +1,277 @@
+package openapi
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/tidwall/gjson"
+)
+
+func getSchema(t *testing.T, operationID, file string) (gjson.Result, OperationInfo) {
+	t.Helper()
+	schema, info, found, err := GetSchema(operationID, file, SchemaOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatalf("operation %s not found", operationID)
+	}
+	return gjson.Parse(schema), info
+}
+
+func TestGetSchemaReferencedRequestBody(t *testing.T) {
+	schema, info := getSchema(t, "createPet", "testdata/ref-request-body.yaml")
+
+	if info.BodyContentMIME != "application/json" {
+		t.Errorf("got body MIME type %q, want application/json", info.BodyContentMIME)
+	}
+	if got := fmt.Sprint(schema.Get("required").Value()); got != "[requestBodyContent]" {
+		t.Errorf("got required %s", got)
+	}
+	body := schema.Get("properties.requestBodyContent")
+	if got := body.Get("type").String(); got != "object" {
+		t.Errorf("got body type %q, want object", got)
+	}
+	if got := body.Get("description").String(); got != "The pet to add" {
+		t.Errorf("got body description %q, want the request body's description", got)
+	}
+	if !body.Get("properties.name").Exists() || !body.Get("properties.tag").Exists() {
+		t.Errorf("got body properties %s, want name and tag", body.Get("properties").Raw)
+	}
+	if body.Get("properties.id").Exists() {
+		t.Error("got the readOnly id property in the request body")
+	}
+	if got := fmt.Sprint(body.Get("required").Value()); got != "[name]" {
+		t.Errorf("got body required %s", got)
+	}
+}
+
+func TestGetSchemaReferencedRequestBodyWithoutSchema(t *testing.T) {
+	schema, info := getSchema(t, "postRaw", "testdata/ref-request-body.yaml")
+
+	if info.BodyContentMIME != "application/json" {
+		t.Errorf("got body MIME type %q, want application/json", info.BodyContentMIME)
+	}
+	body := schema.Get("properties.requestBodyContent")
+	if !body.Exists() {
+		t.Fatal("got no requestBodyContent argument")
+	}
+	// A media type without a schema takes any value.
+	if body.Get("type").Exists() {
+		t.Errorf("got body type %s, want none", body.Get("type").Raw)
+	}
+}
+
+func TestGetSchemaReferencedParameters(t *testing.T) {
+	schema, info := getSchema(t, "getPet", "testdata/ref-parameters.yaml")
+
+	tests := []struct {
+		arg, typ string
+	}{
+		{"petId", "integer"},
+		{"fields", "array"},
+		{"X-Trace-Id", "string"},
+		// A parameter with content takes the schema of its media type.
+		{"filter", "object"},
+	}
+	for _, tt := range tests {
+		property := schema.Get("properties." + gjson.Escape(tt.arg))
+		if !property.Exists() {
+			t.Errorf("got no %s argument", tt.arg)
+			continue
+		}
+		if got := property.Get("type").String(); got != tt.typ {
+			t.Errorf("got type %q for %s, want %q", got, tt.arg, tt.typ)
+		}
+	}
+	if got := schema.Get("properties.petId.description").String(); got != "The pet's ID" {
+		t.Errorf("got petId description %q", got)
+	}
+	if got := schema.Get("properties.X-Trace-Id.format").String(); got != "uuid" {
+		t.Errorf("got X-Trace-Id format %q, want the referenced schema's uuid", got)
+	}
+	if got := fmt.Sprint(schema.Get("required").Value()); got != "[petId]" {
+		t.Errorf("got required %s, want [petId]", got)
+	}
+
+	locations := map[string][]Parameter{"path": info.PathParams, "query": info.QueryParams, "header": info.HeaderParams}
+	for in, names := range map[string][]string{"path": {"petId"}, "query": {"fields", "filter"}, "header": {"X-Trace-Id"}} {
+		var got []string
+		for _, param := range locations[in] {
+			got = append(got, param.Name)
+		}
+		if fmt.Sprint(got) != fmt.Sprint(names) {
+			t.Errorf("got %s parameters %v, want %v", in, got, names)
+		}
+	}
+}
+
+func TestGetSchemaOperationParameterOverridesPathParameter(t *testing.T) {
+	schema, info := getSchema(t, "getItem", "testdata/parameter-override.yaml")
+
+	if len(info.QueryParams) != 1 {
+		t.Fatalf("got %d query parameters, want the overridden limit once", len(info.QueryParams))
+	}
+	// The header parameter has the same name in another location, so it is kept, and the arguments are qualified by location.
+	if len(info.PathParams) != 1 || len(info.HeaderParams) != 1 {
+		t.Fatalf("got %d path and %d header parameters, want 1 each", len(info.PathParams), len(info.HeaderParams))
+	}
+
+	limit := schema.Get("properties." + gjson.Escape(info.QueryParams[0].ArgName))
+	if got := limit.Get("type").String(); got != "integer" {
+		t.Errorf("got limit type %q, want the operation's integer", got)
+	}
+	if got := limit.Get("description").String(); got != "Operation-level limit" {
+		t.Errorf("got limit description %q, want the operation's", got)
+	}
+	header := schema.Get("properties." + gjson.Escape(info.HeaderParams[0].ArgName))
+	if got := header.Get("description").String(); got != "Path-level header with the same name" {
+		t.Errorf("got header description %q", got)
+	}
+}
+
+func TestGetSchemaServerVariables(t *testing.T) {
+	tests := []struct {
+		name, operationID string
+		vars              map[string]string
+		server            string
+		err               string
+	}{
+		{"document server", "health", nil, "https://api.example.com", ""},
+		{"path server defaults", "listReports", nil, "https://prod.us.reports.example.com", ""},
+		{"path server overrides", "listReports", map[string]string{"env": "staging", "region": "eu"}, "https://staging.eu.reports.example.com", ""},
+		{"path server value not in enum", "listReports", map[string]string{"env": "dev"}, "", `invalid value "dev" for server variable env (must be one of: prod, staging)`},
+		{"operation server default", "createReport", nil, "https://uploads.example.com/v1", ""},
+		{"operation server override", "createReport", map[string]string{"version": "v2"}, "https://uploads.example.com/v2", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, info, found, err := GetSchema(tt.operationID, "testdata/servers.yaml", SchemaOptions{ServerVariables: tt.vars})
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("got error %v, want it to contain %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil || !found {
+				t.Fatalf("got found %t and error %v", found, err)
+			}
+			if info.Server != tt.server {
+				t.Errorf("got server %q, want %q", info.Server, tt.server)
+			}
+		})
+	}
+}
+
+func TestConflictingParametersAreReported(t *testing.T) {
+	tests := []struct {
+		operationID string
+		conflicts   string
+		warnings    string
+	}{
+		{"getItem", "[limit]", "warning: parameter limit of operation getItem is defined at the path and operation level with different schemas; using the operation's definition\n"},
+		// Both levels refer to the same schema, so they only differ in their descriptions.
+		{"getThing", "[]", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operationID, func(t *testing.T) {
+			_, info := getSchema(t, tt.operationID, "testdata/parameter-override.yaml")
+			if got := fmt.Sprint(info.ConflictingParameters); got != tt.conflicts {
+				t.Errorf("got conflicting parameters %s, want %s", got, tt.conflicts)
+			}
+
+			recordRequests(t)
+			var warnings strings.Builder
+			opts := RunOptions{Warnings: &warnings}
+			if _, _, err := Run(tt.operationID, "testdata/parameter-override.yaml", `{"id": "1", "query_limit": 5, "limit": 5}`, opts); err != nil {
+				t.Fatal(err)
+			}
+			if warnings.String() != tt.warnings {
+				t.Errorf("got warnings %q, want %q", warnings.String(), tt.warnings)
+			}
+		})
+	}
+}
+
+func TestReadOnlyPropertiesAreKeptInSharedResponse(t *testing.T) {
+	// The request and response bodies of updatePet are the same Pet component, and only the request body drops its readOnly id.
+	types, found, err := GenerateTypes("updatePet", "testdata/read-only.yaml", "api", false)
+	if err != nil || !found {
+		t.Fatalf("got found %t and error %v", found, err)
+	}
+	_, response, ok := strings.Cut(types, "type UpdatePetResponse ")
+	if !ok {
+		t.Fatalf("got no Go response type:\n%s", types)
+	}
+	if !strings.Contains(response, "`json:\"id\"`") {
+		t.Errorf("got Go response type without the readOnly id:\n%s", types)
+	}
+
+	ts, found, err := GenerateTypeScript("updatePet", "testdata/read-only.yaml", true, false)
+	if err != nil || !found {
+		t.Fatalf("got found %t and error %v", found, err)
+	}
+	if _, response, ok := strings.Cut(ts, "export interface UpdatePetResponse "); !ok || !strings.Contains(response, "id: number;") {
+		t.Errorf("got TypeScript response type without the readOnly id:\n%s", ts)
+	}
+}
+
+// requiredNames returns the names listed in every required array of the schema, at any level.
+func requiredNames(schema gjson.Result) []string {
+	var names []string
+	schema.ForEach(func(key, value gjson.Result) bool {
+		if key.String() == "required" && value.IsArray() {
+			for _, name := range value.Array() {
+				names = append(names, name.String())
+			}
+		} else if value.IsObject() || value.IsArray() {
+			names = append(names, requiredNames(value)...)
+		}
+		return true
+	})
+	return names
+}
+
+func TestGetSchemaStripsRequiredReadOnlyProperties(t *testing.T) {
+	tests := []struct {
+		operationID string
+		stripped    []string
+		required    []string
+	}{
+		{"createPet", []string{"id"}, []string{"name"}},
+		// The readOnly properties are in one allOf subschema and required in both.
+		{"createOwner", []string{"id", "createdAt"}, []string{"name"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.operationID, func(t *testing.T) {
+			schema, _ := getSchema(t, tt.operationID, "testdata/read-only.yaml")
+			body := schema.Get("properties.requestBodyContent")
+
+			required := requiredNames(body)
+			for _, name := range tt.stripped {
+				if slices.Contains(required, name) {
+					t.Errorf("got readOnly property %s in required %v", name, required)
+				}
+				if strings.Contains(body.Raw, `"`+name+`"`) {
+					t.Errorf("got readOnly property %s in the body schema", name)
+				}
+			}
+			for _, name := range tt.required {
+				if !slices.Contains(required, name) {
+					t.Errorf("got required %v, want it to include %s", required, name)
+				}
+			}
+
+			// A body without the readOnly properties used to fail validation because they were still required.
+			rt := recordRequests(t)
+			if _, found, err := Run(tt.operationID, "testdata/read-only.yaml", `{"requestBodyContent": {"name": "Rex"}}`, RunOptions{}); err != nil || !found {
+				t.Fatalf("got found %v, error %v", found, err)
+			}
+			if len(rt.requests) != 1 {
+				t.Errorf("got %d requests, want 1", len(rt.requests))
+			}
+		})
+	}
+}
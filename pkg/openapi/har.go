@@ -0,0 +1,323 @@
+package openapi
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gptscript-ai/openapi-cli/pkg/version"
+)
+
+// HAR is an HTTP Archive, in the 1.2 format.
+type HAR struct {
+	Log HARLog `json:"log"`
+}
+
+type HARLog struct {
+	Version string     `json:"version"`
+	Creator HARCreator `json:"creator"`
+	Entries []HAREntry `json:"entries"`
+}
+
+type HARCreator struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+type HAREntry struct {
+	StartedDateTime string `json:"startedDateTime"`
+	// Time is the total time of the request in milliseconds.
+	Time     float64     `json:"time"`
+	Request  HARRequest  `json:"request"`
+	Response HARResponse `json:"response"`
+	Cache    struct{}    `json:"cache"`
+	Timings  HARTimings  `json:"timings"`
+}
+
+type HARRequest struct {
+	Method      string         `json:"method"`
+	URL         string         `json:"url"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	QueryString []HARNameValue `json:"queryString"`
+	PostData    *HARPostData   `json:"postData,omitempty"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARResponse struct {
+	Status      int            `json:"status"`
+	StatusText  string         `json:"statusText"`
+	HTTPVersion string         `json:"httpVersion"`
+	Cookies     []HARNameValue `json:"cookies"`
+	Headers     []HARNameValue `json:"headers"`
+	Content     HARContent     `json:"content"`
+	RedirectURL string         `json:"redirectURL"`
+	HeadersSize int            `json:"headersSize"`
+	BodySize    int            `json:"bodySize"`
+}
+
+type HARNameValue struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type HARPostData struct {
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text"`
+}
+
+type HARContent struct {
+	Size     int    `json:"size"`
+	MimeType string `json:"mimeType"`
+	Text     string `json:"text,omitempty"`
+	// Encoding is base64 for bodies that aren't valid UTF-8 text.
+	Encoding string `json:"encoding,omitempty"`
+}
+
+// HARTimings are the durations of the phases of the request in milliseconds.
+// Phases that didn't happen, such as DNS for a reused connection, are -1.
+type HARTimings struct {
+	Blocked float64 `json:"blocked"`
+	DNS     float64 `json:"dns"`
+	Connect float64 `json:"connect"`
+	Send    float64 `json:"send"`
+	Wait    float64 `json:"wait"`
+	Receive float64 `json:"receive"`
+	SSL     float64 `json:"ssl"`
+}
+
+// HARArchive collects the entries of the requests that are run with it, so that all the requests of a command
+// are written as one HAR.
+type HARArchive struct {
+	mu      sync.Mutex
+	entries []HAREntry
+}
+
+func NewHARArchive() *HARArchive {
+	return &HARArchive{}
+}
+
+func (a *HARArchive) add(entry HAREntry) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+}
+
+// Write writes the HAR with the entries of all the requests so far, in the order their responses were read.
+func (a *HARArchive) Write(w io.Writer) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	har := HAR{Log: HARLog{
+		Version: "1.2",
+		Creator: HARCreator{Name: "openapi-cli", Version: version.Version},
+		Entries: append([]HAREntry{}, a.entries...),
+	}}
+	data, err := json.MarshalIndent(har, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal HAR: %w", err)
+	}
+	if _, err := w.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write HAR: %w", err)
+	}
+	return nil
+}
+
+// harRecorder records a request and its response as a HAR entry, timing the phases of the request with an httptrace.
+// When the request is redirected, the timings are the ones of the last request.
+type harRecorder struct {
+	archive *HARArchive
+	request HARRequest
+	start   time.Time
+	dnsStart, dnsDone, connectStart, connectDone, tlsStart, tlsDone,
+	gotConn, wroteRequest, firstByte time.Time
+}
+
+// newHARRecorder records the request, which must be fully built, and returns it with a context that times it.
+// The request body is read into memory, and the request is given a copy of it.
+func newHARRecorder(archive *HARArchive, req *http.Request) (*harRecorder, *http.Request, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		_ = req.Body.Close()
+		setRequestBody(req, bytes.NewBuffer(body))
+	}
+
+	r := &harRecorder{
+		archive: archive,
+		request: HARRequest{
+			Method:      req.Method,
+			URL:         req.URL.String(),
+			HTTPVersion: "HTTP/1.1",
+			Cookies:     harCookies(req.Cookies()),
+			Headers:     harHeaders(req.Header),
+			QueryString: []HARNameValue{},
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+	}
+	query := req.URL.Query()
+	for _, name := range sortedKeys(query) {
+		for _, value := range query[name] {
+			r.request.QueryString = append(r.request.QueryString, HARNameValue{Name: name, Value: value})
+		}
+	}
+	if len(body) > 0 {
+		r.request.PostData = &HARPostData{MimeType: req.Header.Get("Content-Type"), Text: string(body)}
+	}
+
+	trace := &httptrace.ClientTrace{
+		DNSStart:             func(httptrace.DNSStartInfo) { r.dnsStart = time.Now() },
+		DNSDone:              func(httptrace.DNSDoneInfo) { r.dnsDone = time.Now() },
+		ConnectStart:         func(string, string) { r.connectStart = time.Now() },
+		ConnectDone:          func(string, string, error) { r.connectDone = time.Now() },
+		TLSHandshakeStart:    func() { r.tlsStart = time.Now() },
+		TLSHandshakeDone:     func(tls.ConnectionState, error) { r.tlsDone = time.Now() },
+		GotConn:              func(httptrace.GotConnInfo) { r.gotConn = time.Now() },
+		WroteRequest:         func(httptrace.WroteRequestInfo) { r.wroteRequest = time.Now() },
+		GotFirstResponseByte: func() { r.firstByte = time.Now() },
+	}
+	r.start = time.Now()
+	return r, req.WithContext(httptrace.WithClientTrace(req.Context(), trace)), nil
+}
+
+// recordResponse replaces the response body with one that adds the entry to the archive when it is closed,
+// once the whole body has been read through it.
+func (r *harRecorder) recordResponse(resp *http.Response) {
+	resp.Body = &harBody{ReadCloser: resp.Body, recorder: r, resp: resp}
+}
+
+// harBody is a response body that keeps a copy of what is read from it and adds the entry to the archive when it is closed.
+type harBody struct {
+	io.ReadCloser
+	recorder *harRecorder
+	resp     *http.Response
+	body     bytes.Buffer
+	written  bool
+}
+
+func (b *harBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.body.Write(p[:n])
+	return n, err
+}
+
+func (b *harBody) Close() error {
+	err := b.ReadCloser.Close()
+	if b.written {
+		return err
+	}
+	b.written = true
+	b.recorder.add(b.resp, b.body.Bytes(), time.Now())
+	return err
+}
+
+// add adds the entry with the request and the response, which was received completely at end, to the archive.
+func (r *harRecorder) add(resp *http.Response, body []byte, end time.Time) {
+	content := HARContent{Size: len(body), MimeType: resp.Header.Get("Content-Type")}
+	if utf8.Valid(body) {
+		content.Text = string(body)
+	} else {
+		content.Text = base64.StdEncoding.EncodeToString(body)
+		content.Encoding = "base64"
+	}
+
+	entry := HAREntry{
+		StartedDateTime: r.start.Format("2006-01-02T15:04:05.000Z07:00"),
+		Time:            milliseconds(end.Sub(r.start)),
+		Request:         r.request,
+		Response: HARResponse{
+			Status:      resp.StatusCode,
+			StatusText:  http.StatusText(resp.StatusCode),
+			HTTPVersion: resp.Proto,
+			Cookies:     harCookies(resp.Cookies()),
+			Headers:     harHeaders(resp.Header),
+			Content:     content,
+			RedirectURL: resp.Header.Get("Location"),
+			HeadersSize: -1,
+			BodySize:    len(body),
+		},
+		Timings: r.timings(end),
+	}
+	// The request was sent with the protocol of the response.
+	entry.Request.HTTPVersion = resp.Proto
+
+	r.archive.add(entry)
+}
+
+// timings returns the durations of the phases of the request. The connect time includes the TLS handshake,
+// as the HAR format requires, and blocked is the time spent waiting for a connection apart from DNS and connecting.
+func (r *harRecorder) timings(end time.Time) HARTimings {
+	t := HARTimings{
+		DNS:     phase(r.dnsStart, r.dnsDone),
+		Connect: phase(r.connectStart, r.connectDone),
+		SSL:     phase(r.tlsStart, r.tlsDone),
+		Send:    phase(r.gotConn, r.wroteRequest),
+		Wait:    phase(r.wroteRequest, r.firstByte),
+		Receive: phase(r.firstByte, end),
+	}
+	if t.SSL >= 0 {
+		t.Connect = phase(r.connectStart, r.tlsDone)
+	}
+
+	t.Blocked = -1
+	if !r.gotConn.IsZero() {
+		blocked := r.gotConn.Sub(r.start)
+		if !r.dnsStart.IsZero() && !r.dnsDone.IsZero() {
+			blocked -= r.dnsDone.Sub(r.dnsStart)
+		}
+		if !r.connectStart.IsZero() && !r.connectDone.IsZero() {
+			connectDone := r.connectDone
+			if r.tlsDone.After(connectDone) {
+				connectDone = r.tlsDone
+			}
+			blocked -= connectDone.Sub(r.connectStart)
+		}
+		t.Blocked = milliseconds(max(0, blocked))
+	}
+	return t
+}
+
+// phase returns the duration between the two times in milliseconds, or -1 if either of them didn't happen.
+func phase(start, end time.Time) float64 {
+	if start.IsZero() || end.IsZero() {
+		return -1
+	}
+	return milliseconds(end.Sub(start))
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+func harHeaders(header http.Header) []HARNameValue {
+	result := []HARNameValue{}
+	for _, name := range sortedKeys(header) {
+		for _, value := range header[name] {
+			result = append(result, HARNameValue{Name: name, Value: value})
+		}
+	}
+	return result
+}
+
+func harCookies(cookies []*http.Cookie) []HARNameValue {
+	result := []HARNameValue{}
+	for _, c := range cookies {
+		result = append(result, HARNameValue{Name: c.Name, Value: c.Value})
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripFunc is an http.RoundTripper that calls the function.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestHARArchiveCollectsAllRequests(t *testing.T) {
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		header := http.Header{"Content-Type": {"application/json"}}
+		return &http.Response{StatusCode: http.StatusOK, Header: header, Body: io.NopCloser(strings.NewReader(`{"ok": true}`)), Request: req}, nil
+	})
+	t.Cleanup(func() { http.DefaultClient.Transport = transport })
+
+	archive := NewHARArchive()
+	opts := RunOptions{HAR: archive}
+	for _, id := range []string{"1", "2"} {
+		if _, found, err := Run("getUser", "testdata/overlapping-paths.yaml", `{"id": "`+id+`"}`, opts); err != nil || !found {
+			t.Fatalf("got found %v, error %v", found, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := archive.Write(&buf); err != nil {
+		t.Fatal(err)
+	}
+	// The output must be a single HAR document, not one per request.
+	var har HAR
+	decoder := json.NewDecoder(&buf)
+	if err := decoder.Decode(&har); err != nil {
+		t.Fatal(err)
+	}
+	if decoder.More() {
+		t.Fatal("got more than one JSON document")
+	}
+	if len(har.Log.Entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(har.Log.Entries))
+	}
+	for i, want := range []string{"https://api.example.com/v1/users/1", "https://api.example.com/v1/users/2"} {
+		if got := har.Log.Entries[i].Request.URL; got != want {
+			t.Errorf("entry %d: got URL %s, want %s", i, got, want)
+		}
+		if got := har.Log.Entries[i].Response.Content.Text; got != `{"ok": true}` {
+			t.Errorf("entry %d: got body %s", i, got)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+)
+
+// HookRequest is the request as it is passed to a pre-request hook on stdin.
+type HookRequest struct {
+	Method  string      `json:"method"`
+	URL     string      `json:"url"`
+	Headers http.Header `json:"headers"`
+	// Body is the request body as a string. Bytes that aren't valid UTF-8, like the ones of a gzip or
+	// multipart body, are replaced, so hooks that need the exact bytes, like signers, use BodyBase64.
+	Body string `json:"body"`
+	// BodyBase64 is the request body encoded with standard base64.
+	BodyBase64 string `json:"bodyBase64"`
+}
+
+// HookResponse is what a pre-request hook writes to stdout. Each header is set on the request,
+// replacing any existing values, and headers with an empty value are removed.
+type HookResponse struct {
+	Headers map[string]string `json:"headers"`
+}
+
+// CommandHook is a RequestSigner that runs an external command to modify the request's headers.
+// The command is run with sh -c, receives a HookRequest as JSON on stdin, and must write a HookResponse as JSON to stdout.
+type CommandHook struct {
+	Command string
+}
+
+func (h CommandHook) SignRequest(req *http.Request) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read request body for pre-request hook: %w", err)
+		}
+		_ = req.Body.Close()
+		setRequestBody(req, bytes.NewBuffer(body))
+		req.ContentLength = int64(len(body))
+	}
+
+	input, err := json.Marshal(HookRequest{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Headers:    req.Header,
+		Body:       string(body),
+		BodyBase64: base64.StdEncoding.EncodeToString(body),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal request for pre-request hook: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(req.Context(), "sh", "-c", h.Command)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("pre-request hook failed: %w: %s", err, msg)
+		}
+		return fmt.Errorf("pre-request hook failed: %w", err)
+	}
+
+	var resp HookResponse
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return fmt.Errorf("failed to parse pre-request hook output: %w", err)
+	}
+
+	for name, value := range resp.Headers {
+		if value == "" {
+			req.Header.Del(name)
+		} else {
+			req.Header.Set(name, value)
+		}
+	}
+	return nil
+}
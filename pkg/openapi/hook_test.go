@@ -0,0 +1,94 @@
+package openapi
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommandHookGetsExactBinaryBody(t *testing.T) {
+	// The file isn't valid UTF-8, so the multipart body can only be passed exactly as base64.
+	dir := t.TempDir()
+	upload := filepath.Join(dir, "upload.bin")
+	data := make([]byte, 256)
+	for i := range data {
+		data[i] = byte(i)
+	}
+	if err := os.WriteFile(upload, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rt := recordRequests(t)
+	input := filepath.Join(dir, "hook.json")
+	opts := RunOptions{
+		FieldFiles: map[string][]string{"file": {upload}},
+		Signers:    []RequestSigner{CommandHook{Command: `cat > '` + input + `'; echo '{"headers": {}}'`}},
+	}
+	if _, _, err := Run("upload", "testdata/upload.yaml", `{"requestBodyContent": {"name": "a"}}`, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	hookInput, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hookRequest HookRequest
+	if err := json.Unmarshal(hookInput, &hookRequest); err != nil {
+		t.Fatal(err)
+	}
+	body, err := base64.StdEncoding.DecodeString(hookRequest.BodyBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, []byte(rt.bodies[0])) {
+		t.Fatalf("hook got body %q, server got %q", body, rt.bodies[0])
+	}
+	if !bytes.Contains(body, data) {
+		t.Errorf("got body %q, want it to contain the file", body)
+	}
+}
+
+func TestCommandHookGetsExactGzipBody(t *testing.T) {
+	rt := recordRequests(t)
+	input := filepath.Join(t.TempDir(), "hook.json")
+	opts := RunOptions{
+		CompressRequest: true,
+		Signers:         []RequestSigner{CommandHook{Command: `cat > '` + input + `'; echo '{"headers": {}}'`}},
+	}
+	if _, _, err := Run("createPet", "testdata/extra-headers.yaml", `{"requestBodyContent": {"name": "Rex"}}`, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	hookInput, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var hookRequest HookRequest
+	if err := json.Unmarshal(hookInput, &hookRequest); err != nil {
+		t.Fatal(err)
+	}
+	body, err := base64.StdEncoding.DecodeString(hookRequest.BodyBase64)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(body, []byte(rt.bodies[0])) {
+		t.Fatalf("hook got body %x, server got %x", body, rt.bodies[0])
+	}
+
+	reader, err := gzip.NewReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	decompressed, err := io.ReadAll(reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(bytes.TrimSpace(decompressed)) != `{"name":"Rex"}` {
+		t.Errorf("got decompressed body %s, want %s", decompressed, `{"name":"Rex"}`)
+	}
+}
@@ -0,0 +1,62 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// writeHTTPFile writes the request as a request block of a .http file, which the VS Code REST Client and
+// the JetBrains HTTP client can send: a ### separator with the operation ID, the request line, the headers,
+// and the body after a blank line. The body is read into memory, and the request is given a copy of it.
+func writeHTTPFile(w io.Writer, operationID string, req *http.Request) error {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read request body: %w", err)
+		}
+		_ = req.Body.Close()
+		setRequestBody(req, bytes.NewBuffer(body))
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "### %s\n", operationID)
+	fmt.Fprintf(&b, "%s %s\n", req.Method, req.URL.String())
+
+	header := req.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if req.Host != "" && req.Host != req.URL.Host {
+		header.Set("Host", req.Host)
+	}
+	names := make([]string, 0, len(header))
+	for name := range header {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range header[name] {
+			fmt.Fprintf(&b, "%s: %s\n", name, value)
+		}
+	}
+
+	if len(body) > 0 {
+		b.WriteString("\n")
+		b.Write(body)
+		if !bytes.HasSuffix(body, []byte("\n")) {
+			b.WriteString("\n")
+		}
+	}
+	b.WriteString("\n")
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// KeyValueArgs adds the arguments from name=value lines to the input, converting each value to the type
+// of its property in the operation's schema. Nested properties are named with dots (e.g. requestBodyContent.name),
+// repeating the name of an array property adds an item to it, and objects are given as JSON.
+// Empty lines and lines starting with # are skipped. The values from the lines replace the ones in the input.
+func KeyValueArgs(schemaJSON, input string, r io.Reader) (string, error) {
+	var (
+		names  []string
+		values = map[string][]string{}
+	)
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return "", fmt.Errorf("invalid argument line %q: expected name=value", line)
+		}
+		name = strings.TrimSpace(name)
+		if _, ok := values[name]; !ok {
+			names = append(names, name)
+		}
+		values[name] = append(values[name], value)
+	}
+	if err := scanner.Err(); err != nil {
+		return "", fmt.Errorf("failed to read arguments: %w", err)
+	}
+
+	args := map[string]any{}
+	if strings.TrimSpace(input) != "" {
+		decoder := json.NewDecoder(strings.NewReader(input))
+		decoder.UseNumber()
+		if err := decoder.Decode(&args); err != nil {
+			return "", fmt.Errorf("failed to parse input: %w", err)
+		}
+	}
+	for _, name := range names {
+		schema, path := keyValueProperty(schemaJSON, name)
+		if schema.Get("type").String() != "array" && len(values[name]) > 1 {
+			return "", fmt.Errorf("argument %s is given more than once, but it isn't an array", name)
+		}
+		if err := setKeyValueArg(args, path, keyValueArg(schema, values[name])); err != nil {
+			return "", err
+		}
+	}
+
+	result, err := json.Marshal(args)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	return string(result), nil
+}
+
+// keyValueProperty returns the schema of the property with the name and the path to it in the arguments.
+// A name is only split at its dots when the schema doesn't have a property with the whole name.
+func keyValueProperty(schemaJSON, name string) (gjson.Result, []string) {
+	if property := gjson.Get(schemaJSON, "properties."+gjson.Escape(name)); property.Exists() {
+		return property, []string{name}
+	}
+
+	path := strings.Split(name, ".")
+	schema := gjson.Parse(schemaJSON)
+	for _, part := range path {
+		schema = schema.Get("properties." + gjson.Escape(part))
+	}
+	return schema, path
+}
+
+// keyValueArg converts the values given for a property to the type of its schema. Objects, and arrays
+// given as a single JSON array, are parsed as JSON.
+func keyValueArg(schema gjson.Result, values []string) any {
+	value := strings.TrimSpace(values[0])
+	switch schema.Get("type").String() {
+	case "object":
+		if json.Valid([]byte(value)) {
+			return json.RawMessage(value)
+		}
+		return values[0]
+	case "array":
+		if len(values) == 1 && strings.HasPrefix(value, "[") && json.Valid([]byte(value)) {
+			return json.RawMessage(value)
+		}
+	}
+	return typedArgValue(schema, values)
+}
+
+// setKeyValueArg sets the value at the path in the arguments, creating the objects along the way.
+// It is an error if the path goes through a value that isn't an object.
+func setKeyValueArg(args map[string]any, path []string, value any) error {
+	for i, part := range path[:len(path)-1] {
+		next, ok := args[part]
+		if !ok {
+			next = map[string]any{}
+			args[part] = next
+		}
+		object, ok := next.(map[string]any)
+		if !ok {
+			return fmt.Errorf("argument %s is not an object", strings.Join(path[:i+1], "."))
+		}
+		args = object
+	}
+
+	args[path[len(path)-1]] = value
+	return nil
+}
@@ -0,0 +1,119 @@
+package openapi
+
+import (
+	"bytes"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// newLoader returns a loader for OpenAPI documents. With lenientJSON set, comments and trailing commas
+// are removed from JSON documents, including the ones that are referenced, before they are parsed.
+func newLoader(lenientJSON bool) *openapi3.Loader {
+	loader := openapi3.NewLoader()
+	if lenientJSON {
+		loader.ReadFromURIFunc = func(loader *openapi3.Loader, location *url.URL) ([]byte, error) {
+			data, err := openapi3.DefaultReadFromURI(loader, location)
+			if err != nil || !isJSONDocument(location.Path, data) {
+				return data, err
+			}
+			return stripJSONExtras(data), nil
+		}
+	}
+	return loader
+}
+
+// isJSONDocument returns whether a document is JSON rather than YAML, based on its extension or,
+// when it doesn't have a JSON or YAML extension, on its first character.
+func isJSONDocument(name string, data []byte) bool {
+	switch strings.ToLower(path.Ext(name)) {
+	case ".json":
+		return true
+	case ".yaml", ".yml":
+		return false
+	}
+	trimmed := bytes.TrimSpace(data)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '/')
+}
+
+// stripJSONExtras removes // and /* */ comments and trailing commas in objects and arrays from JSON.
+// Comments are replaced with spaces, keeping their newlines, so that the line numbers in parse errors
+// still match the file.
+func stripJSONExtras(data []byte) []byte {
+	out := make([]byte, 0, len(data))
+	for i := 0; i < len(data); i++ {
+		c := data[i]
+		switch {
+		case c == '"':
+			end := stringEnd(data, i)
+			out = append(out, data[i:end]...)
+			i = end - 1
+		case c == '/' && i+1 < len(data) && data[i+1] == '/':
+			for i < len(data) && data[i] != '\n' {
+				out = append(out, ' ')
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(data) && data[i+1] == '*':
+			end := bytes.Index(data[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(data)
+			} else {
+				end += i + 4
+			}
+			for ; i < end; i++ {
+				if data[i] == '\n' {
+					out = append(out, '\n')
+				} else {
+					out = append(out, ' ')
+				}
+			}
+			i--
+		default:
+			out = append(out, c)
+		}
+	}
+
+	// Now that there are no comments left, drop each comma that is only followed by whitespace and a closing bracket.
+	result := out[:0:0]
+	for i := 0; i < len(out); i++ {
+		c := out[i]
+		switch c {
+		case '"':
+			end := stringEnd(out, i)
+			result = append(result, out[i:end]...)
+			i = end - 1
+			continue
+		case ',':
+			next := i + 1
+			for next < len(out) && isJSONSpace(out[next]) {
+				next++
+			}
+			if next < len(out) && (out[next] == '}' || out[next] == ']') {
+				result = append(result, ' ')
+				continue
+			}
+		}
+		result = append(result, c)
+	}
+	return result
+}
+
+// stringEnd returns the index right after the end of the JSON string that starts at data[start].
+func stringEnd(data []byte, start int) int {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return len(data)
+}
+
+func isJSONSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
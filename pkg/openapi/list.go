@@ -1,36 +1,198 @@
 package openapi
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
+	"sort"
 
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
 type OperationList struct {
 	Operations map[string]Operation `json:"operations"`
+	// ExternalDocs links to more documentation about the whole API.
+	ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
 }
 
 type Operation struct {
-	Description string `json:"description,omitempty"`
-	Summary     string `json:"summary,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Summary     string     `json:"summary,omitempty"`
+	Callbacks   []Callback `json:"callbacks,omitempty"`
+	// Tags are the operation's tags. They are only output as the groups of a TaggedOperationList.
+	Tags []string `json:"-"`
+	// Webhook is the name of the webhook that defines the operation, for operations from the document's
+	// webhooks section. These are requests that the API sends, so they can be inspected but not run.
+	Webhook string `json:"webhook,omitempty"`
+	// ExternalDocs links to more documentation about the operation.
+	ExternalDocs *ExternalDocs `json:"externalDocs,omitempty"`
+	// Extensions are the operation's x- extensions that were asked for, keyed by their full names.
+	Extensions map[string]any `json:"extensions,omitempty"`
 }
 
-func List(file string) (OperationList, error) {
-	loader := openapi3.NewLoader()
-	t, err := loader.LoadFromFile(file)
+// TaggedOperationList is an OperationList grouped by tag. It maps each tag to the operations
+// that have it, keyed by operation ID.
+type TaggedOperationList struct {
+	Tags         map[string]map[string]Operation `json:"tags"`
+	ExternalDocs *ExternalDocs                   `json:"externalDocs,omitempty"`
+}
+
+// DefaultTag is the group of the operations that don't have any tags.
+const DefaultTag = "default"
+
+// GroupByTag groups the operations by tag. Operations with several tags are in each of their groups,
+// and operations without tags are in the DefaultTag group.
+func (l OperationList) GroupByTag() TaggedOperationList {
+	tags := map[string]map[string]Operation{}
+	for operationID, operation := range l.Operations {
+		operationTags := operation.Tags
+		if len(operationTags) == 0 {
+			operationTags = []string{DefaultTag}
+		}
+		for _, tag := range operationTags {
+			if tags[tag] == nil {
+				tags[tag] = map[string]Operation{}
+			}
+			tags[tag][operationID] = operation
+		}
+	}
+	return TaggedOperationList{Tags: tags, ExternalDocs: l.ExternalDocs}
+}
+
+// Callback is a request that the API may make back to the caller of an operation.
+type Callback struct {
+	Name        string `json:"name"`
+	Expression  string `json:"expression"`
+	Method      string `json:"method"`
+	OperationID string `json:"operationId,omitempty"`
+}
+
+// ListOptions are optional settings that control what List includes.
+type ListOptions struct {
+	// Callbacks includes the callbacks declared by each operation.
+	Callbacks bool
+	// LenientJSON allows comments and trailing commas in JSON documents.
+	LenientJSON bool
+	// Extensions are the x- extensions to include for each operation, or * for all of them. See SchemaOptions.Extensions.
+	Extensions []string
+	// Warnings receives a warning for each webhook operation that is left out because its ID is already
+	// taken by another operation. Warnings are discarded if it is nil.
+	Warnings io.Writer
+}
+
+// List returns the operations in the file, keyed by operation ID, including the operations of its webhooks.
+// A webhook operation whose ID is already taken is left out, so that it can't hide an operation that can be run.
+func List(file string, opts ListOptions) (OperationList, error) {
+	t, err := newLoader(opts.LenientJSON).LoadFromFile(file)
 	if err != nil {
-		return OperationList{}, fmt.Errorf("failed to load OpenAPI file %s: %w", file, err)
+		return OperationList{}, &LoadError{File: file, Err: err}
 	}
 
 	operations := make(map[string]Operation)
 	for _, pathItem := range t.Paths.Map() {
 		for _, operation := range pathItem.Operations() {
-			operations[operation.OperationID] = Operation{
-				Description: operation.Description,
-				Summary:     operation.Summary,
+			op := Operation{
+				Description:  operation.Description,
+				Summary:      operation.Summary,
+				Tags:         operation.Tags,
+				ExternalDocs: externalDocs(operation.ExternalDocs),
+				Extensions:   selectExtensions(operation.Extensions, opts.Extensions),
+			}
+			if opts.Callbacks {
+				op.Callbacks = listCallbacks(operation.Callbacks)
 			}
+			operations[operation.OperationID] = op
 		}
 	}
 
-	return OperationList{Operations: operations}, nil
+	hooks, err := webhooks(t)
+	if err != nil {
+		return OperationList{}, err
+	}
+	for _, name := range sortedKeys(hooks) {
+		hookOperations := hooks[name].Operations()
+		for _, method := range sortedKeys(hookOperations) {
+			operation := hookOperations[method]
+			operationID := operation.OperationID
+			if operationID == "" {
+				// Webhook operations often don't have an ID, so they are listed by the webhook's name.
+				operationID = name
+				if len(hookOperations) > 1 {
+					operationID += "." + method
+				}
+			}
+			if _, ok := operations[operationID]; ok {
+				if opts.Warnings != nil {
+					_, _ = fmt.Fprintf(opts.Warnings, "warning: operation %s of webhook %s is left out, since another operation has the same ID\n", operationID, name)
+				}
+				continue
+			}
+			operations[operationID] = Operation{
+				Description:  operation.Description,
+				Summary:      operation.Summary,
+				Tags:         operation.Tags,
+				Webhook:      name,
+				ExternalDocs: externalDocs(operation.ExternalDocs),
+				Extensions:   selectExtensions(operation.Extensions, opts.Extensions),
+			}
+		}
+	}
+
+	return OperationList{Operations: operations, ExternalDocs: externalDocs(t.ExternalDocs)}, nil
+}
+
+// webhooks returns the path items of the document's OpenAPI 3.1 webhooks, keyed by webhook name.
+// The loader doesn't know about webhooks and keeps them as an extension, so their references aren't resolved.
+func webhooks(t *openapi3.T) (map[string]*openapi3.PathItem, error) {
+	raw, ok := t.Extensions["webhooks"]
+	if !ok {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal webhooks: %w", err)
+	}
+	var result map[string]*openapi3.PathItem
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse webhooks: %w", err)
+	}
+	for name, pathItem := range result {
+		if pathItem == nil {
+			delete(result, name)
+		}
+	}
+	return result, nil
+}
+
+// listCallbacks returns every request defined by the callbacks, sorted by name, expression, and method.
+func listCallbacks(callbacks openapi3.Callbacks) []Callback {
+	var result []Callback
+	for name, callback := range callbacks {
+		if callback == nil || callback.Value == nil {
+			continue
+		}
+
+		for expression, pathItem := range callback.Value.Map() {
+			for method, operation := range pathItem.Operations() {
+				result = append(result, Callback{
+					Name:        name,
+					Expression:  expression,
+					Method:      method,
+					OperationID: operation.OperationID,
+				})
+			}
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		if result[i].Expression != result[j].Expression {
+			return result[i].Expression < result[j].Expression
+		}
+		return result[i].Method < result[j].Method
+	})
+	return result
 }
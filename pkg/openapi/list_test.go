@@ -0,0 +1,29 @@
+package openapi
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestListKeepsOperationsOverWebhooksWithTheSameID(t *testing.T) {
+	var warnings strings.Builder
+	list, err := List("testdata/webhook-collision.yaml", ListOptions{Warnings: &warnings})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if op := list.Operations["createPet"]; op.Webhook != "" || op.Summary != "Create a pet" {
+		t.Errorf("got createPet %+v, want the operation from paths", op)
+	}
+	if op := list.Operations["petDeleted"]; op.Webhook != "petDeleted" {
+		t.Errorf("got petDeleted %+v, want the webhook", op)
+	}
+	if len(list.Operations) != 2 {
+		t.Errorf("got %d operations, want 2", len(list.Operations))
+	}
+
+	want := "warning: operation createPet of webhook petCreated is left out, since another operation has the same ID\n"
+	if warnings.String() != want {
+		t.Errorf("got warnings %q, want %q", warnings.String(), want)
+	}
+}
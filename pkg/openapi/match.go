@@ -0,0 +1,80 @@
+package openapi
+
+import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// matchPath matches a request path against an OpenAPI path template like /users/{id},
+// and returns the values of the template's path parameters if it matches.
+func matchPath(template, path string) (map[string]string, bool) {
+	var (
+		pattern strings.Builder
+		names   []string
+		last    int
+	)
+	pattern.WriteString("^")
+	for _, loc := range pathPlaceholderRegexp.FindAllStringSubmatchIndex(template, -1) {
+		pattern.WriteString(regexp.QuoteMeta(template[last:loc[0]]))
+		pattern.WriteString("([^/]+)")
+		names = append(names, strings.TrimSuffix(template[loc[2]:loc[3]], "*"))
+		last = loc[1]
+	}
+	pattern.WriteString(regexp.QuoteMeta(template[last:]))
+	pattern.WriteString("/?$")
+
+	matches := regexp.MustCompile(pattern.String()).FindStringSubmatch(path)
+	if matches == nil {
+		return nil, false
+	}
+
+	values := make(map[string]string, len(names))
+	for i, name := range names {
+		value, err := url.PathUnescape(matches[i+1])
+		if err != nil {
+			value = matches[i+1]
+		}
+		values[name] = value
+	}
+	return values, true
+}
+
+// compareTemplates compares path templates by how specific they are, so that a request path is matched against
+// /users/me before /users/{id}. Templates are compared segment by segment, and a literal segment is more specific
+// than one with a placeholder. It returns a negative number if a is more specific, and 0 if they are equally specific.
+func compareTemplates(a, b string) int {
+	aSegments, bSegments := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < min(len(aSegments), len(bSegments)); i++ {
+		aLiteral := !pathPlaceholderRegexp.MatchString(aSegments[i])
+		bLiteral := !pathPlaceholderRegexp.MatchString(bSegments[i])
+		switch {
+		case aLiteral && !bLiteral:
+			return -1
+		case !aLiteral && bLiteral:
+			return 1
+		}
+	}
+	return len(pathPlaceholderRegexp.FindAllString(a, -1)) - len(pathPlaceholderRegexp.FindAllString(b, -1))
+}
+
+// sortedTemplates returns the path templates of the paths, most specific first. Equally specific templates are
+// sorted by name, so that requests are always routed the same way.
+func sortedTemplates(paths *openapi3.Paths) []string {
+	templates := sortedKeys(paths.Map())
+	slices.SortStableFunc(templates, compareTemplates)
+	return templates
+}
+
+// sortedKeys returns the keys of the map in order.
+func sortedKeys[M ~map[string]V, V any](m M) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
@@ -0,0 +1,144 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/tidwall/gjson"
+)
+
+// PaginateOptions control how Paginate follows the pages of an operation that is paginated with a
+// page number or offset query parameter.
+type PaginateOptions struct {
+	// Param is the argument name of the query parameter that selects the page.
+	Param string
+	// Offset treats the parameter as an offset into the results, which is advanced by the number of items
+	// in each page, instead of a page number, which is advanced by one. Parameters named offset or skip
+	// are always treated as offsets.
+	Offset bool
+	// ItemsPath is the gjson path of the array of items in each response body. The whole body is the array if it is empty.
+	ItemsPath string
+	// TotalPath is the gjson path of the total number of items in each response body.
+	// When it is set, no more pages are requested once that many items have been received.
+	TotalPath string
+	// HasMorePath is the gjson path of a boolean in each response body that is true when there are more pages.
+	// When it is set, no more pages are requested once it is false or missing.
+	HasMorePath string
+	// MaxPages is the most pages to request. Zero means there is no limit.
+	MaxPages int
+}
+
+// Paginate runs an operation once for each page of its results, starting from the value of the page parameter
+// in the arguments, or from page 1 or offset 0, until a page has no items or the total or has-more indicator
+// says there are no more. The response has the items of all the pages as a JSON array in its body, and the
+// status code and headers of the last page. If a page gets an error status, its response is returned as it is.
+// Return values in order: response, found (bool), error.
+func Paginate(operationID, file, args string, opts RunOptions, popts PaginateOptions) (Response, bool, error) {
+	if args == "" {
+		args = "{}"
+	}
+
+	_, opInfo, found, err := GetSchema(operationID, file, SchemaOptions{IgnoreCase: opts.IgnoreCase, ServerVariables: opts.ServerVariables, ServerIndex: opts.ServerIndex, BaseURL: opts.BaseURL, LenientJSON: opts.LenientJSON})
+	if err != nil || !found {
+		return Response{}, found, err
+	}
+	i := slices.IndexFunc(opInfo.QueryParams, func(p Parameter) bool { return p.ArgName == popts.Param })
+	if i < 0 {
+		return Response{}, false, fmt.Errorf("operation %s has no query parameter %s to paginate with", opInfo.OperationID, popts.Param)
+	}
+	if name := strings.ToLower(opInfo.QueryParams[i].Name); name == "offset" || name == "skip" {
+		popts.Offset = true
+	}
+
+	position := int64(1)
+	if popts.Offset {
+		position = 0
+	}
+	if value := gjson.Get(args, gjson.Escape(popts.Param)); value.Exists() {
+		position = value.Int()
+	}
+
+	var (
+		items    = []json.RawMessage{}
+		response Response
+	)
+	for page := 1; popts.MaxPages == 0 || page <= popts.MaxPages; page++ {
+		pageArgs, err := setArg(args, popts.Param, position)
+		if err != nil {
+			return Response{}, false, err
+		}
+
+		resp, found, err := RunResponse(operationID, file, pageArgs, opts)
+		if err != nil || !found {
+			return Response{}, found, err
+		}
+		if resp.StatusCode >= 400 {
+			return resp, true, nil
+		}
+		if page == 1 {
+			response.URL = resp.URL
+		}
+		response.StatusCode, response.Header = resp.StatusCode, resp.Header
+
+		pageItems := gjson.Parse(resp.Body)
+		if popts.ItemsPath != "" {
+			pageItems = pageItems.Get(popts.ItemsPath)
+		}
+		if !pageItems.IsArray() {
+			return Response{}, false, fmt.Errorf("the items of page %d are not an array", page)
+		}
+		pageCount := 0
+		pageItems.ForEach(func(_, item gjson.Result) bool {
+			items = append(items, json.RawMessage(item.Raw))
+			pageCount++
+			return true
+		})
+
+		if pageCount == 0 {
+			break
+		}
+		if popts.TotalPath != "" {
+			if total := gjson.Get(resp.Body, popts.TotalPath); !total.Exists() || int64(len(items)) >= total.Int() {
+				break
+			}
+		}
+		if popts.HasMorePath != "" && !gjson.Get(resp.Body, popts.HasMorePath).Bool() {
+			break
+		}
+
+		if popts.Offset {
+			position += int64(pageCount)
+		} else {
+			position++
+		}
+	}
+
+	body, err := json.Marshal(items)
+	if err != nil {
+		return Response{}, false, fmt.Errorf("failed to marshal items: %w", err)
+	}
+	response.Body = string(body)
+	return response, true, nil
+}
+
+// setArg returns the arguments with the top-level argument set to the value.
+func setArg(args, name string, value any) (string, error) {
+	var values map[string]any
+	decoder := json.NewDecoder(strings.NewReader(args))
+	decoder.UseNumber()
+	if err := decoder.Decode(&values); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+	if values == nil {
+		values = map[string]any{}
+	}
+	values[name] = value
+
+	result, err := json.Marshal(values)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal arguments: %w", err)
+	}
+	return string(result), nil
+}
@@ -0,0 +1,90 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// OperationSchemas describes an operation's parameters and request body separately, keeping the location
+// and serialization of each parameter that the merged arguments schema from GetSchema leaves out.
+type OperationSchemas struct {
+	Parameters []ParameterSchema `json:"parameters"`
+	Body       *BodySchema       `json:"body,omitempty"`
+	// Components holds the component schemas when references are kept.
+	Components map[string]any `json:"components,omitempty"`
+}
+
+// ParameterSchema is a parameter of an operation with its schema.
+type ParameterSchema struct {
+	Name string `json:"name"`
+	In   string `json:"in"`
+	// ArgName is the name of the parameter in the arguments passed to Run.
+	ArgName  string              `json:"argName"`
+	Required bool                `json:"required"`
+	Style    string              `json:"style"`
+	Explode  bool                `json:"explode"`
+	Schema   *openapi3.SchemaRef `json:"schema"`
+}
+
+// BodySchema is the request body of an operation with its schema.
+type BodySchema struct {
+	ContentType string              `json:"contentType"`
+	Schema      *openapi3.SchemaRef `json:"schema"`
+}
+
+// GetParameterSchemas returns the schemas of an operation's parameters and request body, as JSON.
+// Return values in order: schemas JSON (string), found (bool), error.
+func GetParameterSchemas(operationID, file string, opts SchemaOptions) (string, bool, error) {
+	t, err := newLoader(opts.LenientJSON).LoadFromFile(file)
+	if err != nil {
+		return "", false, &LoadError{File: file, Err: err}
+	}
+
+	// The body is described separately, so it is never used as the root of the arguments.
+	opts.BodyIsRoot = false
+	arguments, info, found, err := operationArguments(t, operationID, opts)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	if opts.RequiredOnly {
+		removeOptionalProperties(arguments, map[*openapi3.Schema]bool{})
+	}
+
+	schemas := OperationSchemas{Parameters: []ParameterSchema{}}
+	for _, params := range [][]Parameter{info.PathParams, info.QueryParams, info.HeaderParams, info.CookieParams} {
+		for _, param := range params {
+			if arguments.Properties[param.ArgName] == nil {
+				// The parameter was removed because it is optional.
+				continue
+			}
+			schemas.Parameters = append(schemas.Parameters, ParameterSchema{
+				Name:     param.Name,
+				In:       param.In,
+				ArgName:  param.ArgName,
+				Required: slices.Contains(arguments.Required, param.ArgName),
+				Style:    param.style(),
+				Explode:  param.explode(),
+				Schema:   arguments.Properties[param.ArgName],
+			})
+		}
+	}
+	if body := arguments.Properties["requestBodyContent"]; body != nil {
+		schemas.Body = &BodySchema{
+			ContentType: info.BodyContentMIME,
+			Schema:      body,
+		}
+	}
+	if opts.KeepRefs && t.Components != nil && len(t.Components.Schemas) > 0 {
+		schemas.Components = map[string]any{"schemas": t.Components.Schemas}
+	}
+
+	result, err := json.MarshalIndent(schemas, "", "    ")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal parameter schemas: %w", err)
+	}
+	return string(result), true, nil
+}
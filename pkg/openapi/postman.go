@@ -0,0 +1,352 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+const postmanSchemaURL = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+// PostmanCollection is a Postman collection in the v2.1 format.
+type PostmanCollection struct {
+	Info     PostmanInfo       `json:"info"`
+	Item     []PostmanItem     `json:"item"`
+	Variable []PostmanKeyValue `json:"variable,omitempty"`
+}
+
+type PostmanInfo struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	Schema      string `json:"schema"`
+}
+
+// PostmanItem is either a folder, which has items, or a request.
+type PostmanItem struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Item        []PostmanItem   `json:"item,omitempty"`
+	Request     *PostmanRequest `json:"request,omitempty"`
+}
+
+type PostmanRequest struct {
+	Method      string            `json:"method"`
+	Header      []PostmanKeyValue `json:"header"`
+	URL         PostmanURL        `json:"url"`
+	Body        *PostmanBody      `json:"body,omitempty"`
+	Auth        *PostmanAuth      `json:"auth,omitempty"`
+	Description string            `json:"description,omitempty"`
+}
+
+type PostmanURL struct {
+	Raw      string            `json:"raw"`
+	Host     []string          `json:"host"`
+	Path     []string          `json:"path"`
+	Query    []PostmanKeyValue `json:"query,omitempty"`
+	Variable []PostmanKeyValue `json:"variable,omitempty"`
+}
+
+type PostmanKeyValue struct {
+	Key         string `json:"key"`
+	Value       string `json:"value"`
+	Type        string `json:"type,omitempty"`
+	Description string `json:"description,omitempty"`
+	Disabled    bool   `json:"disabled,omitempty"`
+}
+
+type PostmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw,omitempty"`
+	URLEncoded []PostmanKeyValue `json:"urlencoded,omitempty"`
+	FormData   []PostmanKeyValue `json:"formdata,omitempty"`
+	Options    map[string]any    `json:"options,omitempty"`
+}
+
+// PostmanAuth is the authentication of a request. The field with the name of the type holds its settings.
+type PostmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []PostmanKeyValue `json:"bearer,omitempty"`
+	Basic  []PostmanKeyValue `json:"basic,omitempty"`
+	APIKey []PostmanKeyValue `json:"apikey,omitempty"`
+}
+
+// postmanBaseURLVariable is the collection variable that holds the URL of the document's server.
+const postmanBaseURLVariable = "baseUrl"
+
+// ExportPostman converts the operations in an OpenAPI document into a Postman v2.1 collection, as JSON.
+// Operations are put in a folder for their first tag, and operations without tags are at the top level.
+// Parameters and bodies are filled in with sample values from their schemas, and optional query parameters
+// are disabled. Credentials are left as {{variables}} for Postman to fill in. lenientJSON allows comments
+// and trailing commas in JSON documents.
+func ExportPostman(file string, lenientJSON bool) (string, error) {
+	t, err := newLoader(lenientJSON).LoadFromFile(file)
+	if err != nil {
+		return "", &LoadError{File: file, Err: err}
+	}
+
+	collection := PostmanCollection{
+		Info: PostmanInfo{Schema: postmanSchemaURL},
+		Item: []PostmanItem{},
+	}
+	if t.Info != nil {
+		collection.Info.Name = t.Info.Title
+		collection.Info.Description = t.Info.Description
+	}
+
+	var baseURL string
+	if len(t.Servers) > 0 {
+		if baseURL, err = parseServer(t.Servers[0], nil); err == nil {
+			collection.Variable = append(collection.Variable, PostmanKeyValue{Key: postmanBaseURLVariable, Value: baseURL})
+		}
+	}
+
+	var (
+		folders     []PostmanItem
+		folderIndex = map[string]int{}
+	)
+	for _, path := range sortedKeys(t.Paths.Map()) {
+		pathItem := t.Paths.Value(path)
+		for _, method := range sortedKeys(pathItem.Operations()) {
+			operation := pathItem.GetOperation(method)
+			if operation.OperationID == "" {
+				continue
+			}
+
+			item, err := postmanItem(t, operation, baseURL)
+			if err != nil {
+				return "", fmt.Errorf("failed to convert operation %s: %w", operation.OperationID, err)
+			}
+
+			if len(operation.Tags) == 0 {
+				collection.Item = append(collection.Item, item)
+				continue
+			}
+			tag := operation.Tags[0]
+			i, ok := folderIndex[tag]
+			if !ok {
+				i = len(folders)
+				folderIndex[tag] = i
+				folders = append(folders, PostmanItem{Name: tag, Description: tagDescription(t, tag)})
+			}
+			folders[i].Item = append(folders[i].Item, item)
+		}
+	}
+	collection.Item = append(folders, collection.Item...)
+
+	output, err := json.MarshalIndent(collection, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal Postman collection: %w", err)
+	}
+	return string(output), nil
+}
+
+// postmanItem converts an operation into a Postman request item.
+func postmanItem(t *openapi3.T, operation *openapi3.Operation, baseURL string) (PostmanItem, error) {
+	arguments, info, found, err := operationArguments(t, operation.OperationID, SchemaOptions{})
+	if err != nil {
+		return PostmanItem{}, err
+	} else if !found {
+		return PostmanItem{}, fmt.Errorf("operation not found")
+	}
+
+	name := operation.Summary
+	if name == "" {
+		name = operation.OperationID
+	}
+	request := &PostmanRequest{
+		Method:      strings.ToUpper(info.Method),
+		Header:      []PostmanKeyValue{},
+		Description: operation.Description,
+	}
+
+	var (
+		sm       = sampler{request: true}
+		required = map[string]bool{}
+	)
+	for _, argName := range arguments.Required {
+		required[argName] = true
+	}
+	argument := func(param Parameter) (string, string) {
+		property := arguments.Properties[param.ArgName]
+		if property == nil || property.Value == nil {
+			return "", ""
+		}
+		return postmanValue(sm.sampleValue(property)), property.Value.Description
+	}
+
+	host := info.Server
+	if host == "" || host == baseURL {
+		host = "{{" + postmanBaseURLVariable + "}}"
+	}
+	request.URL = PostmanURL{
+		Host: []string{host},
+		Path: []string{},
+	}
+	for _, segment := range strings.Split(strings.Trim(postmanPathVariables(info.Path), "/"), "/") {
+		if segment != "" {
+			request.URL.Path = append(request.URL.Path, segment)
+		}
+	}
+	for _, param := range info.PathParams {
+		value, description := argument(param)
+		request.URL.Variable = append(request.URL.Variable, PostmanKeyValue{Key: param.Name, Value: value, Description: description})
+	}
+	for _, param := range info.QueryParams {
+		value, description := argument(param)
+		request.URL.Query = append(request.URL.Query, PostmanKeyValue{
+			Key:         param.Name,
+			Value:       value,
+			Description: description,
+			Disabled:    !required[param.ArgName],
+		})
+	}
+	for _, param := range info.HeaderParams {
+		value, description := argument(param)
+		request.Header = append(request.Header, PostmanKeyValue{Key: param.Name, Value: value, Description: description})
+	}
+	if len(info.CookieParams) > 0 {
+		var cookies []string
+		for _, param := range info.CookieParams {
+			value, _ := argument(param)
+			cookies = append(cookies, param.Name+"="+value)
+		}
+		request.Header = append(request.Header, PostmanKeyValue{Key: "Cookie", Value: strings.Join(cookies, "; ")})
+	}
+	request.URL.Raw = postmanRawURL(request.URL)
+
+	if info.BodyContentMIME != "" {
+		body := arguments.Properties["requestBodyContent"]
+		var sample any
+		if body != nil {
+			sample = sm.sampleValue(body)
+		}
+		request.Body = postmanBody(info.BodyContentMIME, sample, body)
+		request.Header = append(request.Header, PostmanKeyValue{Key: "Content-Type", Value: info.BodyContentMIME})
+	}
+
+	request.Auth = postmanAuth(info.Security)
+
+	return PostmanItem{Name: name, Request: request}, nil
+}
+
+var postmanPathVariableRegexp = regexp.MustCompile(`\{([^{}/]+)}`)
+
+// postmanPathVariables replaces the {name} placeholders in a path with Postman's :name path variables.
+func postmanPathVariables(path string) string {
+	return postmanPathVariableRegexp.ReplaceAllString(path, ":$1")
+}
+
+// postmanRawURL returns the full URL of a Postman request, with its enabled query parameters.
+func postmanRawURL(u PostmanURL) string {
+	raw := strings.Join(u.Host, ".")
+	if len(u.Path) > 0 {
+		raw += "/" + strings.Join(u.Path, "/")
+	}
+
+	var query []string
+	for _, q := range u.Query {
+		if !q.Disabled {
+			query = append(query, q.Key+"="+q.Value)
+		}
+	}
+	if len(query) > 0 {
+		raw += "?" + strings.Join(query, "&")
+	}
+	return raw
+}
+
+// postmanBody returns the Postman body for a sample request body of the media type.
+// Binary multipart fields are file fields, which are left for the user to pick a file for.
+func postmanBody(mediaType string, sample any, schema *openapi3.SchemaRef) *PostmanBody {
+	switch mediaType {
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		fields, _ := sample.(map[string]any)
+		var values []PostmanKeyValue
+		for _, key := range sortedKeys(fields) {
+			if mediaType == "multipart/form-data" && isBinaryProperty(schema, key) {
+				values = append(values, PostmanKeyValue{Key: key, Type: "file"})
+				continue
+			}
+			values = append(values, PostmanKeyValue{Key: key, Value: postmanValue(fields[key]), Type: "text"})
+		}
+		if mediaType == "multipart/form-data" {
+			return &PostmanBody{Mode: "formdata", FormData: values}
+		}
+		return &PostmanBody{Mode: "urlencoded", URLEncoded: values}
+	case "text/plain":
+		return &PostmanBody{Mode: "raw", Raw: postmanValue(sample)}
+	}
+
+	raw, _ := json.MarshalIndent(sample, "", "    ")
+	return &PostmanBody{
+		Mode:    "raw",
+		Raw:     string(raw),
+		Options: map[string]any{"raw": map[string]string{"language": "json"}},
+	}
+}
+
+// isBinaryProperty returns whether a property of the object schema is binary data, or an array of it.
+func isBinaryProperty(schema *openapi3.SchemaRef, name string) bool {
+	if schema == nil || schema.Value == nil {
+		return false
+	}
+	property := schema.Value.Properties[name]
+	if property != nil && property.Value != nil && property.Value.Items != nil {
+		property = property.Value.Items
+	}
+	return property != nil && property.Value != nil && property.Value.Format == "binary"
+}
+
+// postmanAuth returns the Postman authentication for the first set of security schemes that Postman supports.
+// Credentials are left as variables.
+func postmanAuth(security [][]SecurityScheme) *PostmanAuth {
+	for _, schemes := range security {
+		if len(schemes) != 1 {
+			continue
+		}
+		scheme := schemes[0]
+		switch {
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "bearer"), scheme.Type == "oauth2", scheme.Type == "openIdConnect":
+			return &PostmanAuth{Type: "bearer", Bearer: []PostmanKeyValue{
+				{Key: "token", Value: "{{bearerToken}}", Type: "string"},
+			}}
+		case scheme.Type == "http" && strings.EqualFold(scheme.Scheme, "basic"):
+			return &PostmanAuth{Type: "basic", Basic: []PostmanKeyValue{
+				{Key: "username", Value: "{{username}}", Type: "string"},
+				{Key: "password", Value: "{{password}}", Type: "string"},
+			}}
+		case scheme.Type == "apiKey" && scheme.In != "cookie":
+			return &PostmanAuth{Type: "apikey", APIKey: []PostmanKeyValue{
+				{Key: "key", Value: scheme.ParamName, Type: "string"},
+				{Key: "value", Value: "{{apiKey}}", Type: "string"},
+				{Key: "in", Value: scheme.In, Type: "string"},
+			}}
+		}
+	}
+	return nil
+}
+
+// postmanValue returns the string form of a sample value: scalars as they are, and anything else as JSON.
+func postmanValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case map[string]any, []any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(value)
+}
+
+// tagDescription returns the description of a tag from the document's tag list.
+func tagDescription(t *openapi3.T, name string) string {
+	if tag := t.Tags.Get(name); tag != nil {
+		return tag.Description
+	}
+	return ""
+}
@@ -0,0 +1,45 @@
+package openapi
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimiter throttles requests with a token bucket. The bucket holds a single token, so requests
+// are spaced evenly instead of being sent in bursts. A RateLimiter can be shared by several Runs,
+// so that all of them together stay under the rate.
+type RateLimiter struct {
+	mu     sync.Mutex
+	rate   float64
+	tokens float64
+	last   time.Time
+}
+
+// NewRateLimiter returns a RateLimiter that allows the given number of requests per second.
+func NewRateLimiter(requestsPerSecond float64) (*RateLimiter, error) {
+	if requestsPerSecond <= 0 {
+		return nil, fmt.Errorf("rate limit must be greater than 0, got %v", requestsPerSecond)
+	}
+	return &RateLimiter{rate: requestsPerSecond, tokens: 1}, nil
+}
+
+// Wait blocks until a request is allowed to be sent.
+func (l *RateLimiter) Wait() {
+	l.mu.Lock()
+	now := time.Now()
+	if !l.last.IsZero() {
+		l.tokens = min(1, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+
+	// Take the token even if it isn't there yet. The bucket goes negative, which makes
+	// concurrent callers wait in turn.
+	l.tokens--
+	wait := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
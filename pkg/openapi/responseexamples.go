@@ -0,0 +1,117 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// ResponseExampleResult is the outcome of checking one of an operation's response examples against the response schema.
+type ResponseExampleResult struct {
+	// Status is the response's status code as it is declared, such as 200 or 4XX.
+	Status    string `json:"status"`
+	MediaType string `json:"mediaType"`
+	// Example is the name of the example, or "example" for the media type's single example.
+	Example string   `json:"example"`
+	Valid   bool     `json:"valid"`
+	Errors  []string `json:"errors,omitempty"`
+}
+
+// ValidateResponseExamples checks the example and examples of each of the operation's JSON responses against
+// the response's schema, without sending any request. Examples that are only given as an external value are skipped.
+// The results are sorted by status, media type, and example name. The IgnoreCase and LenientJSON options are used.
+// Return values in order: results, found (bool), error.
+func ValidateResponseExamples(operationID, file string, opts SchemaOptions) ([]ResponseExampleResult, bool, error) {
+	t, err := newLoader(opts.LenientJSON).LoadFromFile(file)
+	if err != nil {
+		return nil, false, &LoadError{File: file, Err: err}
+	}
+
+	if opts.IgnoreCase {
+		if operationID, err = resolveOperationID(t, operationID); err != nil {
+			return nil, false, err
+		}
+	}
+	operation := findOperation(t, operationID)
+	if operation == nil {
+		return nil, false, nil
+	}
+	if operation.Responses == nil {
+		return []ResponseExampleResult{}, true, nil
+	}
+
+	results := []ResponseExampleResult{}
+	responses := operation.Responses.Map()
+	for _, status := range sortedKeys(responses) {
+		response := responses[status]
+		if response == nil || response.Value == nil {
+			continue
+		}
+		for _, mediaType := range sortedKeys(response.Value.Content) {
+			content := response.Value.Content[mediaType]
+			if !strings.Contains(mediaType, "json") || content == nil || content.Schema == nil || content.Schema.Value == nil {
+				continue
+			}
+
+			examples := map[string]any{}
+			if content.Example != nil {
+				examples["example"] = content.Example
+			}
+			for name, example := range content.Examples {
+				if example != nil && example.Value != nil && example.Value.Value != nil {
+					examples[name] = example.Value.Value
+				}
+			}
+			if len(examples) == 0 {
+				continue
+			}
+
+			schema, err := responseSchemaLoader(t, content.Schema)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to build schema for %s response %s: %w", status, mediaType, err)
+			}
+			for _, name := range sortedKeys(examples) {
+				result := ResponseExampleResult{Status: status, MediaType: mediaType, Example: name}
+				validation, err := gojsonschema.Validate(schema, gojsonschema.NewGoLoader(examples[name]))
+				if err != nil {
+					result.Errors = []string{err.Error()}
+				} else {
+					result.Valid = validation.Valid()
+					for _, e := range validation.Errors() {
+						result.Errors = append(result.Errors, e.String())
+					}
+				}
+				results = append(results, result)
+			}
+		}
+	}
+
+	return results, true, nil
+}
+
+// responseSchemaLoader returns a JSON schema loader for a response schema. References are inlined as they are
+// for the arguments, and the component schemas are included so that the ones kept for recursion can be resolved.
+func responseSchemaLoader(t *openapi3.T, schema *openapi3.SchemaRef) (gojsonschema.JSONLoader, error) {
+	removeRefs(schema, componentSchemas(t))
+
+	m, err := schema.Value.MarshalYAML()
+	if err != nil {
+		return nil, err
+	}
+	root, ok := m.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("unexpected schema type %T", m)
+	}
+	if components := componentSchemas(t); len(components) > 0 {
+		root["components"] = map[string]any{"schemas": components}
+	}
+
+	data, err := json.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	return gojsonschema.NewBytesLoader(data), nil
+}
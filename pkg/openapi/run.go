@@ -2,92 +2,360 @@ package openapi
 
 import (
 	"bytes"
+	"compress/gzip"
 	"encoding/json"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/textproto"
 	"net/url"
 	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
+	"github.com/gptscript-ai/openapi-cli/pkg/version"
 	"github.com/tidwall/gjson"
 	"github.com/xeipuuv/gojsonschema"
 )
 
-func Run(operationID, file, args string) (string, bool, error) {
+// RunOptions are optional settings that control how Run builds the request.
+type RunOptions struct {
+	// Query contains extra query parameters that are added after the ones defined by the operation.
+	Query url.Values
+	// Defaults maps operation IDs to default arguments for those operations. The "*" entry applies to all operations.
+	// Arguments are merged in order of increasing precedence: the "*" defaults, the operation's defaults,
+	// the example (if any), and finally the arguments passed to Run.
+	Defaults map[string]map[string]any
+	// BodyIsRoot treats the whole arguments object as the request body, instead of expecting it under
+	// "requestBodyContent", for operations that have a request body and no parameters.
+	BodyIsRoot bool
+	// ExpandEnv replaces ${NAME} references in the string values of the arguments with the values of the
+	// environment variables, after the defaults and example are merged in and before the arguments are
+	// validated. $${ is a literal ${. It is off by default, since arguments may come from untrusted sources.
+	ExpandEnv bool
+	// Example is the name of an example from the operation to use as the base for the arguments.
+	// Any arguments passed to Run are merged on top of the example's values.
+	Example string
+	// FieldFiles maps multipart field names to paths of files to upload as those fields.
+	// Several files for one field are uploaded as several parts with the field's name.
+	FieldFiles map[string][]string
+	// BoolFormat controls how boolean query parameter values are serialized. It defaults to true/false.
+	BoolFormat BoolFormat
+	// ArrayDelimiters maps query parameter names to the delimiter used to join their array values.
+	// The "*" entry applies to all query parameters. A delimiter overrides the parameter's style and
+	// explode settings, so the array is always sent as a single joined value.
+	ArrayDelimiters map[string]string
+	// RepeatedHeaders lists the header parameters whose array values are sent as one header line per item,
+	// instead of a single comma-separated line. The "*" entry applies to all header parameters.
+	RepeatedHeaders []string
+	// NoQueryEncode sends the values of query parameters as they are, without percent-encoding them,
+	// for values that are already encoded. Parameter names are still encoded.
+	NoQueryEncode bool
+	// UserAgent overrides the default User-Agent header.
+	UserAgent string
+	// Accept is the Accept header to send, verbatim, such as a list of media ranges with quality values.
+	// Without it, operations with several success response media types send an Accept header that prefers JSON.
+	Accept string
+	// Headers are extra request headers. They replace any headers of the same name set from the operation's parameters
+	// or for the request body, like Content-Type.
+	Headers http.Header
+	// Method overrides the HTTP method of the operation. No request body is sent for HEAD requests.
+	Method string
+	// ServerVariables overrides the default values of the variables in the operation's server URL.
+	ServerVariables map[string]string
+	// ServerIndex is the index of the server to send the request to in the operation's list of servers.
+	// It defaults to the first one. See OperationInfo.Servers.
+	ServerIndex int
+	// BaseURL replaces the operation's server. See SchemaOptions.BaseURL.
+	BaseURL string
+	// StrictFormats also validates the arguments against the OpenAPI formats that JSON schema
+	// validation doesn't cover, such as the ranges of int32 and int64 and the base64 byte format.
+	// String formats like date-time, email, and uuid are always validated.
+	StrictFormats bool
+	// IgnoreCase matches the operation ID case-insensitively. See SchemaOptions.IgnoreCase.
+	IgnoreCase bool
+	// LenientJSON allows comments and trailing commas in JSON documents.
+	LenientJSON bool
+	// RequestContentType overrides the Content-Type header of the request body. The body is still
+	// built for the operation's declared media type.
+	RequestContentType string
+	// CompressRequest gzips the request body and sets the Content-Encoding header.
+	CompressRequest bool
+	// NoEnvAuth disables reading credentials from the OPENAPI_BEARER, OPENAPI_QUERY_KEY, OPENAPI_API_KEY,
+	// and OPENAPI_AUTH_<SCHEME> environment variables.
+	NoEnvAuth bool
+	// BearerToken, if set, returns the token for bearer schemes instead of OPENAPI_BEARER. It is only called
+	// for operations that have a bearer scheme without their own OPENAPI_AUTH_<SCHEME> variable.
+	BearerToken func() (string, error)
+	// Signers modify the request after it is fully constructed, in order, right before it is sent.
+	Signers []RequestSigner
+	// HTTPFile, if set, receives the request in the .http file format of the VS Code REST Client and the
+	// JetBrains HTTP client, after it is signed and right before it is sent.
+	HTTPFile io.Writer
+	// HAR, if set, collects an HTTP Archive (HAR) entry with the request, the response, and the timings of the request,
+	// once the response body has been read and closed. The same archive can be shared by several requests.
+	HAR *HARArchive
+	// RateLimiter, if set, is waited on before the request is sent.
+	RateLimiter *RateLimiter
+	// Client is the HTTP client used to send the request. It defaults to http.DefaultClient.
+	Client *http.Client
+	// Warnings receives warnings about the operation and its arguments, such as the use of
+	// deprecated operations and parameters. Warnings are discarded if it is nil.
+	Warnings io.Writer
+}
+
+// Response is the response to an operation's request.
+type Response struct {
+	// URL is the final URL the request was sent to, after the path and query parameters were filled in.
+	URL        string
+	StatusCode int
+	Header     http.Header
+	Body       string
+	// RetryAfter is how long the server asked to wait before retrying, from the Retry-After header
+	// of a 429 or 503 response. It is zero if the response has no valid Retry-After header.
+	RetryAfter time.Duration
+}
+
+// BoolFormat is the pair of strings used for true and false values.
+type BoolFormat struct {
+	True, False string
+}
+
+// Run builds and sends the request for an operation and returns the response body.
+// Return values in order: response body (string), found (bool), error.
+func Run(operationID, file, args string, opts RunOptions) (string, bool, error) {
+	resp, found, err := RunResponse(operationID, file, args, opts)
+	return resp.Body, found, err
+}
+
+// RunResponse builds and sends the request for an operation like Run, and returns the response with its
+// status code and headers.
+// Return values in order: response, found (bool), error.
+func RunResponse(operationID, file, args string, opts RunOptions) (Response, bool, error) {
+	resp, found, err := RunHTTP(operationID, file, args, opts)
+	if err != nil || !found {
+		return Response{}, found, err
+	}
+	defer resp.Body.Close()
+
+	result, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Response{}, false, &RequestError{Err: fmt.Errorf("failed to read response: %w", err)}
+	}
+	// Close the body now rather than only in the defer, since a HAR file is written when it is closed.
+	if err := resp.Body.Close(); err != nil {
+		return Response{}, false, err
+	}
+
+	response := Response{
+		URL:        originalRequest(resp).URL.String(),
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header,
+		Body:       string(result),
+	}
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusServiceUnavailable {
+		response.RetryAfter, _ = parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	}
+	return response, true, nil
+}
+
+// originalRequest returns the request that was sent first to get the response, before any redirects.
+func originalRequest(resp *http.Response) *http.Request {
+	req := resp.Request
+	for req.Response != nil && req.Response.Request != nil {
+		req = req.Response.Request
+	}
+	return req
+}
+
+// RunHTTP builds and sends the request for an operation like Run, but returns the response as it is,
+// without reading its body, for callers that need to stream the body or inspect the connection.
+// The caller must close the response body.
+// Return values in order: response, found (bool), error.
+func RunHTTP(operationID, file, args string, opts RunOptions) (*http.Response, bool, error) {
 	if args == "" {
 		args = "{}"
 	}
-	schemaJSON, opInfo, found, err := GetSchema(operationID, file)
+	schemaJSON, opInfo, found, err := GetSchema(operationID, file, SchemaOptions{IgnoreCase: opts.IgnoreCase, ServerVariables: opts.ServerVariables, ServerIndex: opts.ServerIndex, BaseURL: opts.BaseURL, LenientJSON: opts.LenientJSON})
 	if err != nil {
-		return "", false, err
+		return nil, false, err
 	} else if !found {
-		return "", false, nil
+		return nil, false, nil
+	}
+	operationID = opInfo.OperationID
+
+	if opts.BodyIsRoot && opInfo.BodyContentMIME != "" && !opInfo.hasParameters() {
+		args = `{"requestBodyContent":` + args + "}"
+	}
+
+	base := mergeValues(opts.Defaults["*"], opts.Defaults[operationID])
+	if opts.Example != "" {
+		example, ok := opInfo.Examples[opts.Example]
+		if !ok {
+			return nil, false, fmt.Errorf("example %s not found for operation %s (available examples: %s)", opts.Example, operationID, strings.Join(exampleNames(opInfo.Examples), ", "))
+		}
+		base = mergeValues(base, example.Args)
+	}
+
+	if len(base) > 0 {
+		args, err = mergeArgs(base, args)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	if opts.ExpandEnv {
+		if args, err = expandEnvArgs(args); err != nil {
+			return nil, false, err
+		}
+	}
+
+	if len(opts.FieldFiles) > 0 {
+		if opInfo.BodyContentMIME != "multipart/form-data" {
+			return nil, false, fmt.Errorf("files can only be attached to operations with a multipart/form-data request body")
+		}
+
+		// The files take the place of these fields, so make sure the schema sees a value for them.
+		fields := make(map[string]any, len(opts.FieldFiles))
+		for field, paths := range opts.FieldFiles {
+			if gjson.Get(schemaJSON, "properties.requestBodyContent.properties."+gjson.Escape(field)+".type").String() == "array" {
+				placeholders := make([]any, len(paths))
+				for i := range placeholders {
+					placeholders[i] = ""
+				}
+				fields[field] = placeholders
+			} else {
+				fields[field] = ""
+			}
+		}
+		args, err = mergeArgs(map[string]any{"requestBodyContent": fields}, args)
+		if err != nil {
+			return nil, false, err
+		}
+	}
+
+	// Fill in the arguments that can only have one value, so that users don't have to provide them.
+	args, err = FillFixedArgs(schemaJSON, args)
+	if err != nil {
+		return nil, false, err
+	}
+
+	// A HEAD request is sent without the body, so the body isn't required.
+	if opts.Method == http.MethodHead {
+		if schemaJSON, err = withoutRequired(schemaJSON, "requestBodyContent"); err != nil {
+			return nil, false, err
+		}
 	}
 
 	// Validate args against the schema.
 	validationResult, err := gojsonschema.Validate(gojsonschema.NewStringLoader(schemaJSON), gojsonschema.NewStringLoader(args))
 	if err != nil {
-		return "", false, err
+		return nil, false, err
 	}
 
-	if !validationResult.Valid() {
-		return "", false, fmt.Errorf("invalid arguments for operation %s: %s", operationID, validationResult.Errors())
+	validationErr := &ValidationError{OperationID: operationID}
+	for _, e := range validationResult.Errors() {
+		validationErr.Errors = append(validationErr.Errors, e.String())
+	}
+	if opts.StrictFormats {
+		validationErr.Errors = append(validationErr.Errors, checkFormats(schemaJSON, args)...)
+	}
+	if len(validationErr.Errors) > 0 {
+		return nil, false, validationErr
+	}
+
+	if opts.Warnings != nil {
+		warnDeprecated(opts.Warnings, operationID, opInfo, args)
+		warnConflictingParameters(opts.Warnings, operationID, opInfo)
 	}
 
 	// Construct and execute the HTTP request.
 
 	// Handle path parameters.
-	opInfo.Path = handlePathParameters(opInfo.Path, opInfo.PathParams, args)
-
-	// Parse the URL
-	path, err := url.JoinPath(opInfo.Server, opInfo.Path)
+	opInfo.Path, err = handlePathParameters(opInfo.Path, opInfo.PathParams, args)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to join server and path: %w", err)
+		return nil, false, err
 	}
 
+	// Parse the URL
+	path := joinURLPath(opInfo.Server, opInfo.Path)
 	u, err := url.Parse(path)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to parse server URL %s: %w", opInfo.Server+opInfo.Path, err)
+		return nil, false, fmt.Errorf("failed to parse server URL %s: %w", opInfo.Server+opInfo.Path, err)
+	}
+
+	method := opInfo.Method
+	if opts.Method != "" {
+		method = opts.Method
 	}
 
 	// Set up the request
-	req, err := http.NewRequest(opInfo.Method, u.String(), nil)
+	req, err := http.NewRequest(method, u.String(), nil)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to create request: %w", err)
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	// TODO - check for auth
-	if os.Getenv("OPENAPI_BEARER") != "" {
-		req.Header.Set("Authorization", "Bearer "+os.Getenv("OPENAPI_BEARER"))
+	userAgent := opts.UserAgent
+	if userAgent == "" {
+		userAgent = "openapi-cli/" + version.Version
+	}
+	req.Header.Set("User-Agent", userAgent)
+
+	if opts.Accept != "" {
+		req.Header.Set("Accept", opts.Accept)
+	} else if len(opInfo.ResponseContentTypes) > 1 {
+		req.Header.Set("Accept", defaultAccept(opInfo.ResponseContentTypes))
+	}
+
+	var credentials []envCredential
+	if !opts.NoEnvAuth {
+		if credentials, err = envCredentials(opInfo.Security, opts.BearerToken); err != nil {
+			return nil, false, err
+		}
 	}
 
 	// Handle query parameters
-	req.URL.RawQuery = handleQueryParameters(req.URL.Query(), opInfo.QueryParams, args).Encode()
+	q, emptyParams := handleQueryParameters(req.URL.Query(), opInfo.QueryParams, args, opts.BoolFormat, opts.ArrayDelimiters)
+	for name, values := range opts.Query {
+		for _, value := range values {
+			q.Add(name, value)
+		}
+	}
 
-	if os.Getenv("OPENAPI_QUERY_KEY") != "" {
-		req.URL.RawQuery += "&" + "key=" + url.QueryEscape(os.Getenv("OPENAPI_QUERY_KEY"))
+	for _, credential := range credentials {
+		if credential.Scheme.Type == "apiKey" && credential.Scheme.In == "query" {
+			q.Add(credential.Scheme.ParamName, credential.Value)
+		}
 	}
+	req.URL.RawQuery = encodeQuery(q, emptyParams, queryValueEscaper(opInfo.QueryParams, opts.NoQueryEncode))
 
 	// Handle header and cookie parameters
-	handleHeaderParameters(req, opInfo.HeaderParams, args)
+	handleHeaderParameters(req, opInfo.HeaderParams, args, opts.RepeatedHeaders)
 	handleCookieParameters(req, opInfo.CookieParams, args)
+	setEnvCredentials(req, credentials)
 
 	// Handle request body
-	if opInfo.BodyContentMIME != "" {
+	if opInfo.BodyContentMIME != "" && method != http.MethodHead {
 		res := gjson.Get(args, "requestBodyContent")
 		var body bytes.Buffer
+		var bodyReader io.Reader = &body
 		switch opInfo.BodyContentMIME {
 		case "application/json":
 			var reqBody interface{}
 
 			reqBody = struct{}{}
 			if res.Exists() {
-				reqBody = res.Value()
+				// Use the raw JSON so that large integers keep their precision.
+				reqBody = json.RawMessage(res.Raw)
 			}
 			if err := json.NewEncoder(&body).Encode(reqBody); err != nil {
-				return "", false, fmt.Errorf("failed to encode JSON: %w", err)
+				return nil, false, fmt.Errorf("failed to encode JSON: %w", err)
 			}
 			req.Header.Set("Content-Type", "application/json")
 
@@ -101,127 +369,478 @@ func Run(operationID, file, args string) (string, bool, error) {
 			req.Header.Set("Content-Type", "text/plain")
 
 		case "multipart/form-data":
-			multiPartWriter := multipart.NewWriter(&body)
-			req.Header.Set("Content-Type", multiPartWriter.FormDataContentType())
-			if res.Exists() && res.IsObject() {
-				for k, v := range res.Map() {
-					if err := multiPartWriter.WriteField(k, v.String()); err != nil {
-						return "", false, fmt.Errorf("failed to write multipart field: %w", err)
+			if !res.Exists() || !res.IsObject() {
+				return nil, false, fmt.Errorf("multipart/form-data requires an object as the requestBodyContent")
+			}
+			for field, paths := range opts.FieldFiles {
+				for _, path := range paths {
+					if _, err := os.Stat(path); err != nil {
+						return nil, false, fmt.Errorf("failed to read file for field %s: %w", field, err)
 					}
 				}
-			} else {
-				return "", false, fmt.Errorf("multipart/form-data requires an object as the requestBodyContent")
-			}
-			if err := multiPartWriter.Close(); err != nil {
-				return "", false, fmt.Errorf("failed to close multipart writer: %w", err)
 			}
 
+			// Stream the multipart body so that uploaded files don't need to be held in memory.
+			pr, pw := io.Pipe()
+			multiPartWriter := multipart.NewWriter(pw)
+			req.Header.Set("Content-Type", multiPartWriter.FormDataContentType())
+			go func() {
+				pw.CloseWithError(writeMultipartBody(multiPartWriter, res, opInfo.BodyEncoding, opts.FieldFiles))
+			}()
+			bodyReader = pr
+
 		default:
-			return "", false, fmt.Errorf("unsupported MIME type: %s", opInfo.BodyContentMIME)
+			return nil, false, fmt.Errorf("unsupported MIME type: %s", opInfo.BodyContentMIME)
+		}
+
+		if opts.RequestContentType != "" {
+			req.Header.Set("Content-Type", opts.RequestContentType)
+		}
+
+		if opts.CompressRequest {
+			req.Header.Set("Content-Encoding", "gzip")
+			if bodyReader, err = gzipBody(bodyReader); err != nil {
+				return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+			}
+		}
+		setRequestBody(req, bodyReader)
+	} else if method == http.MethodPost || method == http.MethodPut || method == http.MethodPatch {
+		// Some servers reject these methods without a body unless they have an explicit Content-Length: 0.
+		setRequestBody(req, &bytes.Buffer{})
+	}
+
+	// The extra headers are set after the body, so that they replace the headers that go with it, like Content-Type.
+	for name, values := range opts.Headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			req.Header.Add(name, value)
+		}
+	}
+
+	// A streamed body is written by a goroutine that only stops once the body is read or closed. The client
+	// closes it once the request is sent, so close it here if the request fails before that.
+	sent, body := false, req.Body
+	defer func() {
+		if !sent && body != nil {
+			_ = body.Close()
+		}
+	}()
+
+	for _, signer := range opts.Signers {
+		if err := signer.SignRequest(req); err != nil {
+			return nil, false, fmt.Errorf("failed to sign request: %w", err)
+		}
+	}
+
+	if opts.HTTPFile != nil {
+		if err := writeHTTPFile(opts.HTTPFile, operationID, req); err != nil {
+			return nil, false, err
 		}
-		req.Body = io.NopCloser(&body)
 	}
 
 	// Make the request
-	resp, err := http.DefaultClient.Do(req)
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	if opts.RateLimiter != nil {
+		opts.RateLimiter.Wait()
+	}
+	var har *harRecorder
+	if opts.HAR != nil {
+		if har, req, err = newHARRecorder(opts.HAR, req); err != nil {
+			return nil, false, err
+		}
+	}
+	sent = true
+	resp, err := client.Do(req)
 	if err != nil {
-		return "", false, fmt.Errorf("failed to make request: %w", err)
+		return nil, false, &RequestError{Err: fmt.Errorf("failed to make request: %w", err)}
 	}
-	defer resp.Body.Close()
+	if har != nil {
+		har.recordResponse(resp)
+	}
+	return resp, true, nil
+}
 
-	result, err := io.ReadAll(resp.Body)
+// parseRetryAfter parses the value of a Retry-After header, which is either a number of seconds
+// or an HTTP date, into the duration to wait from now. Dates in the past give a duration of zero.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(0, date.Sub(now)), true
+	}
+	return 0, false
+}
+
+// writeMultipartBody writes the fields of the object and the files to the multipart body and closes it.
+func writeMultipartBody(w *multipart.Writer, object gjson.Result, encoding map[string]Encoding, files map[string][]string) error {
+	if err := writeMultipartFields(w, object, encoding, files); err != nil {
+		return err
+	}
+	if err := writeMultipartFiles(w, files); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+	return nil
+}
+
+// writeMultipartFields writes each property of the object as a part of the multipart body.
+// Properties with an encoding are written with the encoding's content type and headers,
+// and JSON content types receive the raw JSON value. Each item of an array is written as a separate
+// part with the property's name, unless the array is sent as JSON. Fields that have a file attached are skipped.
+// The parts are written in the order of the object's properties.
+func writeMultipartFields(w *multipart.Writer, object gjson.Result, encoding map[string]Encoding, files map[string][]string) error {
+	for _, entry := range objectEntries(object) {
+		k, v := entry.key, entry.value
+		if _, ok := files[k]; ok {
+			continue
+		}
+
+		enc := encoding[k]
+		values := []gjson.Result{v}
+		if v.IsArray() && !strings.Contains(enc.ContentType, "json") {
+			values = v.Array()
+		}
+		for _, value := range values {
+			if err := writeMultipartField(w, k, value, enc); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeMultipartField writes a single value of a property as a part of the multipart body.
+func writeMultipartField(w *multipart.Writer, k string, v gjson.Result, enc Encoding) error {
+	if enc.ContentType == "" && len(enc.Headers) == 0 {
+		if err := w.WriteField(k, valueString(v)); err != nil {
+			return fmt.Errorf("failed to write multipart field: %w", err)
+		}
+		return nil
+	}
+
+	h := textproto.MIMEHeader{}
+	for name, value := range enc.Headers {
+		h.Set(name, value)
+	}
+	h.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"`, quoteEscaper.Replace(k)))
+	if enc.ContentType != "" {
+		h.Set("Content-Type", enc.ContentType)
+	}
+
+	part, err := w.CreatePart(h)
+	if err != nil {
+		return fmt.Errorf("failed to create multipart part: %w", err)
+	}
+
+	value := valueString(v)
+	if strings.Contains(enc.ContentType, "json") {
+		value = v.Raw
+	}
+	if _, err := io.WriteString(part, value); err != nil {
+		return fmt.Errorf("failed to write multipart field: %w", err)
+	}
+	return nil
+}
+
+// writeMultipartFiles writes the content of each file as a file part of the multipart body, sorted by field name.
+func writeMultipartFiles(w *multipart.Writer, files map[string][]string) error {
+	for _, field := range sortedKeys(files) {
+		for _, path := range files[field] {
+			if err := writeMultipartFile(w, field, path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func writeMultipartFile(w *multipart.Writer, field, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open file for field %s: %w", field, err)
+	}
+	defer f.Close()
+
+	part, err := w.CreateFormFile(field, filepath.Base(path))
+	if err != nil {
+		return fmt.Errorf("failed to create multipart file part: %w", err)
+	}
+	if _, err := io.Copy(part, f); err != nil {
+		return fmt.Errorf("failed to write file %s for field %s: %w", path, field, err)
+	}
+	return nil
+}
+
+var quoteEscaper = strings.NewReplacer("\\", "\\\\", `"`, "\\\"")
+
+// gzipBody returns the gzip-compressed body. Buffered bodies are compressed up front so that they stay buffered,
+// and streamed bodies are compressed as they are read, through a pipe whose reader is returned.
+func gzipBody(body io.Reader) (io.Reader, error) {
+	if buffered, ok := body.(*bytes.Buffer); ok {
+		var compressed bytes.Buffer
+		zw := gzip.NewWriter(&compressed)
+		if _, err := zw.Write(buffered.Bytes()); err != nil {
+			return nil, err
+		}
+		if err := zw.Close(); err != nil {
+			return nil, err
+		}
+		return &compressed, nil
+	}
+
+	// The pipe reader is returned as it is, so that closing the request body stops the compressor, which then
+	// closes a streamed source body in turn, like a multipart pipe.
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		_, err := io.Copy(zw, body)
+		if err == nil {
+			err = zw.Close()
+		}
+		if closer, ok := body.(io.Closer); ok {
+			_ = closer.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// setRequestBody sets the body of the request. Buffered bodies are sent with a Content-Length
+// and can be sent again if the request is redirected or retried. Streamed bodies are sent chunked,
+// and closing the request body closes them, so that a pipe's writer stops if the body isn't read.
+func setRequestBody(req *http.Request, body io.Reader) {
+	buffered, ok := body.(*bytes.Buffer)
+	if !ok {
+		if rc, ok := body.(io.ReadCloser); ok {
+			req.Body = rc
+		} else {
+			req.Body = io.NopCloser(body)
+		}
+		return
+	}
+
+	data := buffered.Bytes()
+	req.ContentLength = int64(len(data))
+	if len(data) == 0 {
+		// Like http.NewRequest, use NoBody so that the transport knows the body is empty and sends Content-Length: 0.
+		req.Body = http.NoBody
+		req.GetBody = func() (io.ReadCloser, error) {
+			return http.NoBody, nil
+		}
+		return
+	}
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(data)), nil
+	}
+	req.Body, _ = req.GetBody()
+}
+
+// warnDeprecated writes a warning if the operation is deprecated, and for each deprecated parameter given a value in the args.
+func warnDeprecated(w io.Writer, operationID string, opInfo OperationInfo, args string) {
+	if opInfo.Deprecated {
+		_, _ = fmt.Fprintf(w, "warning: operation %s is deprecated\n", operationID)
+	}
+
+	for _, params := range [][]Parameter{opInfo.PathParams, opInfo.QueryParams, opInfo.HeaderParams, opInfo.CookieParams} {
+		for _, param := range params {
+			if param.Deprecated && gjson.Get(args, argPath(param)).Exists() {
+				_, _ = fmt.Fprintf(w, "warning: parameter %s of operation %s is deprecated\n", param.Name, operationID)
+			}
+		}
+	}
+}
+
+// warnConflictingParameters writes a warning for each parameter that has conflicting definitions at the path and operation level.
+func warnConflictingParameters(w io.Writer, operationID string, opInfo OperationInfo) {
+	for _, name := range opInfo.ConflictingParameters {
+		_, _ = fmt.Fprintf(w, "warning: parameter %s of operation %s is defined at the path and operation level with different schemas; using the operation's definition\n", name, operationID)
+	}
+}
+
+// exampleNames returns the sorted names of the examples.
+func exampleNames(examples map[string]Example) []string {
+	names := make([]string, 0, len(examples))
+	for name := range examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// withoutRequired returns the schema JSON with the property removed from the schema's required properties.
+func withoutRequired(schemaJSON, name string) (string, error) {
+	var schema map[string]any
+	decoder := json.NewDecoder(strings.NewReader(schemaJSON))
+	decoder.UseNumber()
+	if err := decoder.Decode(&schema); err != nil {
+		return "", fmt.Errorf("failed to parse schema: %w", err)
+	}
+
+	required, _ := schema["required"].([]any)
+	required = slices.DeleteFunc(required, func(r any) bool { return r == name })
+	if len(required) > 0 {
+		schema["required"] = required
+	} else {
+		delete(schema, "required")
+	}
+
+	result, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal schema: %w", err)
+	}
+	return string(result), nil
+}
+
+// mergeArgs merges the args JSON on top of the base values and returns the result as JSON.
+// Nested objects are merged recursively, and any other values in args replace those in base.
+func mergeArgs(base map[string]any, args string) (string, error) {
+	// Use json.Number to avoid losing the precision of numbers in the arguments.
+	var overrides map[string]any
+	decoder := json.NewDecoder(strings.NewReader(args))
+	decoder.UseNumber()
+	if err := decoder.Decode(&overrides); err != nil {
+		return "", fmt.Errorf("failed to parse arguments: %w", err)
+	}
+
+	merged, err := json.Marshal(mergeValues(base, overrides))
 	if err != nil {
-		return "", false, fmt.Errorf("failed to read response: %w", err)
+		return "", fmt.Errorf("failed to marshal arguments: %w", err)
 	}
+	return string(merged), nil
+}
 
-	return string(result), true, nil
+func mergeValues(base, overrides map[string]any) map[string]any {
+	result := make(map[string]any, len(base)+len(overrides))
+	for k, v := range base {
+		result[k] = v
+	}
+	for k, v := range overrides {
+		baseObject, baseOK := result[k].(map[string]any)
+		overrideObject, overrideOK := v.(map[string]any)
+		if baseOK && overrideOK {
+			result[k] = mergeValues(baseObject, overrideObject)
+		} else {
+			result[k] = v
+		}
+	}
+	return result
 }
 
-// handlePathParameters extracts each path parameter from the input JSON and replaces its placeholder in the URL path.
-func handlePathParameters(path string, params []Parameter, input string) string {
+var pathPlaceholderRegexp = regexp.MustCompile(`\{([^{}/]+)}`)
+
+// handlePathParameters extracts each path parameter from the input JSON and replaces its placeholder in the URL path,
+// every time it appears. Placeholders are matched with their braces, so {id} doesn't match inside {idempotencyKey},
+// and values are percent-encoded, braces included, so a value can't be taken for the placeholder of a later parameter.
+// It returns an error if any placeholders are left without a value.
+func handlePathParameters(path string, params []Parameter, input string) (string, error) {
 	for _, param := range params {
-		res := gjson.Get(input, param.Name)
+		placeholder := "{" + param.Name + "}"
+		if strings.Contains(path, "{"+param.Name+"*}") {
+			// The template uses the RFC 6570 explode modifier, which takes precedence over the parameter definition.
+			placeholder = "{" + param.Name + "*}"
+			explode := true
+			param.Explode = &explode
+		}
+
+		res := gjson.Get(input, argPath(param))
 		if res.Exists() {
 			// If it's an array or object, handle the serialization style
-			if res.IsArray() {
+			// Array returns the object itself as the only item of an object, so objects are checked with Map.
+			if res.IsArray() && len(res.Array()) == 0 || res.IsObject() && len(res.Map()) == 0 {
+				// Empty arrays and objects are undefined in RFC 6570, so they expand to nothing in every style.
+				path = strings.ReplaceAll(path, placeholder, "")
+			} else if res.IsArray() {
 				switch param.Style {
 				case "simple", "": // simple is the default style for path parameters
 					// simple looks the same regardless of whether explode is true
 					strs := make([]string, len(res.Array()))
 					for i, item := range res.Array() {
-						strs[i] = item.String()
+						strs[i] = url.PathEscape(valueString(item))
 					}
-					path = strings.Replace(path, "{"+param.Name+"}", strings.Join(strs, ","), 1)
+					path = strings.ReplaceAll(path, placeholder, strings.Join(strs, ","))
 				case "label":
 					strs := make([]string, len(res.Array()))
 					for i, item := range res.Array() {
-						strs[i] = item.String()
+						strs[i] = url.PathEscape(valueString(item))
 					}
 
-					if param.Explode == nil || !*param.Explode { // default is to not explode
-						path = strings.Replace(path, "{"+param.Name+"}", "."+strings.Join(strs, ","), 1)
+					if !param.explode() {
+						path = strings.ReplaceAll(path, placeholder, "."+strings.Join(strs, ","))
 					} else {
-						path = strings.Replace(path, "{"+param.Name+"}", "."+strings.Join(strs, "."), 1)
+						path = strings.ReplaceAll(path, placeholder, "."+strings.Join(strs, "."))
 					}
 				case "matrix":
 					strs := make([]string, len(res.Array()))
 					for i, item := range res.Array() {
-						strs[i] = item.String()
+						strs[i] = url.PathEscape(valueString(item))
 					}
 
-					if param.Explode == nil || !*param.Explode { // default is to not explode
-						path = strings.Replace(path, "{"+param.Name+"}", ";"+param.Name+"="+strings.Join(strs, ","), 1)
+					if !param.explode() {
+						path = strings.ReplaceAll(path, placeholder, ";"+param.Name+"="+strings.Join(strs, ","))
 					} else {
 						s := ""
 						for _, str := range strs {
 							s += ";" + param.Name + "=" + str
 						}
-						path = strings.Replace(path, "{"+param.Name+"}", s, 1)
+						path = strings.ReplaceAll(path, placeholder, s)
 					}
 				}
 			} else if res.IsObject() {
 				switch param.Style {
 				case "simple", "":
-					if param.Explode == nil || !*param.Explode { // default is to not explode
+					if !param.explode() {
 						var strs []string
-						for k, v := range res.Map() {
-							strs = append(strs, k, v.String())
+						for _, entry := range objectEntries(res) {
+							strs = append(strs, url.PathEscape(entry.key), url.PathEscape(valueString(entry.value)))
 						}
-						path = strings.Replace(path, "{"+param.Name+"}", strings.Join(strs, ","), 1)
+						path = strings.ReplaceAll(path, placeholder, strings.Join(strs, ","))
 					} else {
 						var strs []string
-						for k, v := range res.Map() {
-							strs = append(strs, k+"="+v.String())
+						for _, entry := range objectEntries(res) {
+							strs = append(strs, url.PathEscape(entry.key)+"="+url.PathEscape(valueString(entry.value)))
 						}
-						path = strings.Replace(path, "{"+param.Name+"}", strings.Join(strs, ","), 1)
+						path = strings.ReplaceAll(path, placeholder, strings.Join(strs, ","))
 					}
 				case "label":
-					if param.Explode == nil || !*param.Explode { // default is to not explode
+					if !param.explode() {
 						var strs []string
-						for k, v := range res.Map() {
-							strs = append(strs, k, v.String())
+						for _, entry := range objectEntries(res) {
+							strs = append(strs, url.PathEscape(entry.key), url.PathEscape(valueString(entry.value)))
 						}
-						path = strings.Replace(path, "{"+param.Name+"}", "."+strings.Join(strs, ","), 1)
+						path = strings.ReplaceAll(path, placeholder, "."+strings.Join(strs, ","))
 					} else {
 						s := ""
-						for k, v := range res.Map() {
-							s += "." + k + "=" + v.String()
+						for _, entry := range objectEntries(res) {
+							s += "." + url.PathEscape(entry.key) + "=" + url.PathEscape(valueString(entry.value))
 						}
-						path = strings.Replace(path, "{"+param.Name+"}", s, 1)
+						path = strings.ReplaceAll(path, placeholder, s)
 					}
 				case "matrix":
-					if param.Explode == nil || !*param.Explode { // default is to not explode
+					if !param.explode() {
 						var strs []string
-						for k, v := range res.Map() {
-							strs = append(strs, k, v.String())
+						for _, entry := range objectEntries(res) {
+							strs = append(strs, url.PathEscape(entry.key), url.PathEscape(valueString(entry.value)))
 						}
-						path = strings.Replace(path, "{"+param.Name+"}", ";"+param.Name+"="+strings.Join(strs, ","), 1)
+						path = strings.ReplaceAll(path, placeholder, ";"+param.Name+"="+strings.Join(strs, ","))
 					} else {
 						s := ""
-						for k, v := range res.Map() {
-							s += ";" + k + "=" + v.String()
+						for _, entry := range objectEntries(res) {
+							s += ";" + url.PathEscape(entry.key) + "=" + url.PathEscape(valueString(entry.value))
 						}
-						path = strings.Replace(path, "{"+param.Name+"}", s, 1)
+						path = strings.ReplaceAll(path, placeholder, s)
 					}
 				}
 			} else {
@@ -229,59 +848,270 @@ func handlePathParameters(path string, params []Parameter, input string) string
 				// Explode doesn't do anything though.
 				switch param.Style {
 				case "simple", "":
-					path = strings.Replace(path, "{"+param.Name+"}", res.String(), 1)
+					path = strings.ReplaceAll(path, placeholder, url.PathEscape(valueString(res)))
 				case "label":
-					path = strings.Replace(path, "{"+param.Name+"}", "."+res.String(), 1)
+					path = strings.ReplaceAll(path, placeholder, "."+url.PathEscape(valueString(res)))
 				case "matrix":
-					path = strings.Replace(path, "{"+param.Name+"}", ";"+param.Name+"="+res.String(), 1)
+					if value := valueString(res); value == "" {
+						// An empty value is just the name, like ;color.
+						path = strings.ReplaceAll(path, placeholder, ";"+param.Name)
+					} else {
+						path = strings.ReplaceAll(path, placeholder, ";"+param.Name+"="+url.PathEscape(value))
+					}
 				}
 			}
 		}
 	}
-	return path
+	if missing := pathPlaceholderRegexp.FindAllString(path, -1); len(missing) > 0 {
+		return "", fmt.Errorf("missing value for path parameter(s) %s in path %s", strings.Join(missing, ", "), path)
+	}
+	return path, nil
+}
+
+// joinURLPath joins the server URL and the operation's path. Unlike url.JoinPath, it doesn't clean the path,
+// which would drop the segments that are just the "." of an empty label-style value.
+func joinURLPath(server, path string) string {
+	return strings.TrimRight(server, "/") + "/" + strings.TrimLeft(path, "/")
+}
+
+// envCredential is a credential from the environment for one of the operation's security schemes.
+type envCredential struct {
+	Scheme SecurityScheme
+	// Variable is the environment variable the credential is from.
+	Variable, Value string
+}
+
+var envVariableNameRegexp = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// lookupEnvCredential returns the credential in the environment for a security scheme. The scheme's own
+// variable, OPENAPI_AUTH_ followed by its name in upper case with other characters than letters and digits
+// replaced by underscores, takes precedence over OPENAPI_BEARER for bearer tokens, OPENAPI_QUERY_KEY for
+// query API keys, and OPENAPI_API_KEY for header and cookie API keys. If bearerToken is set, it replaces OPENAPI_BEARER.
+func lookupEnvCredential(scheme SecurityScheme, bearerToken func() (string, error)) (envCredential, bool, error) {
+	variable := "OPENAPI_AUTH_" + strings.ToUpper(envVariableNameRegexp.ReplaceAllString(scheme.Name, "_"))
+	if value := os.Getenv(variable); value != "" && isEnvAuthScheme(scheme) {
+		return envCredential{Scheme: scheme, Variable: variable, Value: value}, true, nil
+	}
+
+	switch {
+	case scheme.Type == "http" && scheme.Scheme == "bearer" || scheme.Type == "oauth2" || scheme.Type == "openIdConnect":
+		variable = "OPENAPI_BEARER"
+		if bearerToken != nil {
+			token, err := bearerToken()
+			if err != nil {
+				return envCredential{}, false, err
+			}
+			return envCredential{Scheme: scheme, Variable: variable, Value: token}, true, nil
+		}
+	case scheme.Type == "apiKey" && scheme.In == "query":
+		variable = "OPENAPI_QUERY_KEY"
+	case scheme.Type == "apiKey" && (scheme.In == "header" || scheme.In == "cookie"):
+		variable = "OPENAPI_API_KEY"
+	default:
+		return envCredential{}, false, nil
+	}
+	if value := os.Getenv(variable); value != "" {
+		return envCredential{Scheme: scheme, Variable: variable, Value: value}, true, nil
+	}
+	return envCredential{}, false, nil
+}
+
+// isEnvAuthScheme returns whether credentials for the scheme can be sent from the environment:
+// bearer tokens and API keys.
+func isEnvAuthScheme(scheme SecurityScheme) bool {
+	switch scheme.Type {
+	case "oauth2", "openIdConnect":
+		return true
+	case "http":
+		return scheme.Scheme == "bearer"
+	case "apiKey":
+		return scheme.In == "query" || scheme.In == "header" || scheme.In == "cookie"
+	}
+	return false
+}
+
+// envCredentials returns the credentials from the environment to send for the operation's security requirements.
+// The schemes of a requirement must all be satisfied together, like a bearer token and an API key header, so the
+// first requirement that has a credential from a different variable for each of its schemes is used. If none does, the credentials for the
+// first scheme that each variable applies to are sent, in case the server accepts them anyway.
+func envCredentials(security [][]SecurityScheme, bearerToken func() (string, error)) ([]envCredential, error) {
+	for _, schemes := range security {
+		var credentials []envCredential
+		for _, scheme := range schemes {
+			credential, ok, err := lookupEnvCredential(scheme, bearerToken)
+			if err != nil {
+				return nil, err
+			}
+			if !ok || slices.ContainsFunc(credentials, func(c envCredential) bool { return c.Variable == credential.Variable }) {
+				credentials = nil
+				break
+			}
+			credentials = append(credentials, credential)
+		}
+		if len(credentials) > 0 {
+			return credentials, nil
+		}
+	}
+
+	var (
+		credentials []envCredential
+		used        = map[string]bool{}
+	)
+	for _, schemes := range security {
+		for _, scheme := range schemes {
+			credential, ok, err := lookupEnvCredential(scheme, bearerToken)
+			if err != nil {
+				return nil, err
+			}
+			if ok && !used[credential.Variable] {
+				used[credential.Variable] = true
+				credentials = append(credentials, credential)
+			}
+		}
+	}
+	return credentials, nil
+}
+
+// setEnvCredentials sets the bearer tokens and header and cookie API keys from the environment on the request,
+// unless the request already has the header or cookie from the arguments.
+func setEnvCredentials(req *http.Request, credentials []envCredential) {
+	for _, credential := range credentials {
+		switch scheme := credential.Scheme; {
+		case scheme.Type == "apiKey" && scheme.In == "header":
+			if req.Header.Get(scheme.ParamName) == "" {
+				req.Header.Set(scheme.ParamName, credential.Value)
+			}
+		case scheme.Type == "apiKey" && scheme.In == "cookie":
+			if _, err := req.Cookie(scheme.ParamName); err != nil {
+				req.AddCookie(&http.Cookie{Name: scheme.ParamName, Value: credential.Value})
+			}
+		case scheme.Type != "apiKey":
+			if req.Header.Get("Authorization") == "" {
+				req.Header.Set("Authorization", "Bearer "+credential.Value)
+			}
+		}
+	}
+}
+
+// objectEntry is a property of a JSON object.
+type objectEntry struct {
+	key   string
+	value gjson.Result
+}
+
+// objectEntries returns the properties of a JSON object in the order they appear in it, so that objects
+// are serialized in a predictable order, unlike when ranging over gjson.Result.Map.
+func objectEntries(res gjson.Result) []objectEntry {
+	var entries []objectEntry
+	res.ForEach(func(key, value gjson.Result) bool {
+		entries = append(entries, objectEntry{key: key.String(), value: value})
+		return true
+	})
+	return entries
+}
+
+// valueString returns the string form of a JSON value for use in a parameter.
+// Numbers use their original JSON representation so that precision and formatting are preserved.
+func valueString(res gjson.Result) string {
+	if res.Type == gjson.Number && res.Raw != "" {
+		return res.Raw
+	}
+	return res.String()
+}
+
+// queryValueString returns the string form of a JSON value for use in a query parameter,
+// serializing booleans according to the format.
+func queryValueString(res gjson.Result, boolFormat BoolFormat) string {
+	switch {
+	case res.Type == gjson.True && boolFormat.True != "":
+		return boolFormat.True
+	case res.Type == gjson.False && boolFormat.False != "":
+		return boolFormat.False
+	}
+	return valueString(res)
+}
+
+// escapeKeepingReserved percent-encodes the characters of the value that are neither unreserved nor reserved.
+func escapeKeepingReserved(value string) string {
+	var b strings.Builder
+	for _, c := range []byte(value) {
+		if isUnreserved(c) || strings.IndexByte(reservedChars, c) >= 0 {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// reservedChars are the reserved characters defined by RFC 3986.
+const reservedChars = ":/?#[]@!$&'()*+,;="
+
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '-' || c == '.' || c == '_' || c == '~'
 }
 
 // handleQueryParameters extracts each query parameter from the input JSON and adds it to the URL query.
-func handleQueryParameters(q url.Values, params []Parameter, input string) url.Values {
+// The names of the parameters that allow empty values and are given an empty string are returned
+// separately, since they are sent without a value. Arrays of parameters that have a delimiter
+// (or when there is a "*" delimiter) are joined with it instead of following their style.
+func handleQueryParameters(q url.Values, params []Parameter, input string, boolFormat BoolFormat, delimiters map[string]string) (url.Values, []string) {
+	var emptyParams []string
 	for _, param := range params {
-		res := gjson.Get(input, param.Name)
+		res := gjson.Get(input, argPath(param))
 		if res.Exists() {
+			if param.AllowEmptyValue && res.Type == gjson.String && res.Str == "" {
+				emptyParams = append(emptyParams, param.Name)
+				continue
+			}
+
+			delimiter, hasDelimiter := delimiters[param.Name]
+			if !hasDelimiter {
+				delimiter, hasDelimiter = delimiters["*"]
+			}
+
 			// If it's an array or object, handle the serialization style
-			if res.IsArray() {
+			if res.IsArray() && hasDelimiter {
+				var strs []string
+				for _, item := range res.Array() {
+					strs = append(strs, queryValueString(item, boolFormat))
+				}
+				q.Add(param.Name, strings.Join(strs, delimiter))
+			} else if res.IsArray() {
 				switch param.Style {
 				case "form", "": // form is the default style for query parameters
-					if param.Explode == nil || *param.Explode { // default is to explode
+					if param.explode() {
 						for _, item := range res.Array() {
-							q.Add(param.Name, item.String())
+							q.Add(param.Name, queryValueString(item, boolFormat))
 						}
 					} else {
 						var strs []string
 						for _, item := range res.Array() {
-							strs = append(strs, item.String())
+							strs = append(strs, queryValueString(item, boolFormat))
 						}
 						q.Add(param.Name, strings.Join(strs, ","))
 					}
 				case "spaceDelimited":
-					if param.Explode == nil || *param.Explode {
+					if param.explode() {
 						for _, item := range res.Array() {
-							q.Add(param.Name, item.String())
+							q.Add(param.Name, queryValueString(item, boolFormat))
 						}
 					} else {
 						var strs []string
 						for _, item := range res.Array() {
-							strs = append(strs, item.String())
+							strs = append(strs, queryValueString(item, boolFormat))
 						}
 						q.Add(param.Name, strings.Join(strs, " "))
 					}
 				case "pipeDelimited":
-					if param.Explode == nil || *param.Explode {
+					if param.explode() {
 						for _, item := range res.Array() {
-							q.Add(param.Name, item.String())
+							q.Add(param.Name, queryValueString(item, boolFormat))
 						}
 					} else {
 						var strs []string
 						for _, item := range res.Array() {
-							strs = append(strs, item.String())
+							strs = append(strs, queryValueString(item, boolFormat))
 						}
 						q.Add(param.Name, strings.Join(strs, "|"))
 					}
@@ -289,90 +1119,175 @@ func handleQueryParameters(q url.Values, params []Parameter, input string) url.V
 			} else if res.IsObject() {
 				switch param.Style {
 				case "form", "": // form is the default style for query parameters
-					if param.Explode == nil || *param.Explode { // default is to explode
-						for k, v := range res.Map() {
-							q.Add(k, v.String())
+					if param.explode() {
+						for _, entry := range objectEntries(res) {
+							q.Add(entry.key, queryValueString(entry.value, boolFormat))
 						}
 					} else {
 						var strs []string
-						for k, v := range res.Map() {
-							strs = append(strs, k, v.String())
+						for _, entry := range objectEntries(res) {
+							strs = append(strs, entry.key, queryValueString(entry.value, boolFormat))
 						}
 						q.Add(param.Name, strings.Join(strs, ","))
 					}
 				case "deepObject":
-					for k, v := range res.Map() {
-						q.Add(param.Name+"["+k+"]", v.String())
+					for _, entry := range objectEntries(res) {
+						q.Add(param.Name+"["+entry.key+"]", queryValueString(entry.value, boolFormat))
 					}
 				}
 			} else {
-				q.Add(param.Name, res.String())
+				q.Add(param.Name, queryValueString(res, boolFormat))
 			}
 		}
 	}
-	return q
+	return q, emptyParams
+}
+
+// encodeQuery encodes the query values sorted by name, like url.Values.Encode, with each value encoded
+// by escapeValue, followed by the names of the parameters that have no value (e.g. "?debug").
+func encodeQuery(q url.Values, emptyParams []string, escapeValue func(name, value string) string) string {
+	names := make([]string, 0, len(q))
+	for name := range q {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(q)+len(emptyParams))
+	for _, name := range names {
+		for _, value := range q[name] {
+			parts = append(parts, url.QueryEscape(name)+"="+escapeValue(name, value))
+		}
+	}
+	for _, name := range emptyParams {
+		parts = append(parts, url.QueryEscape(name))
+	}
+	return strings.Join(parts, "&")
+}
+
+// queryValueEscaper returns the function that encodes the values of query parameters. With noEncode,
+// values are used as they are. The values of parameters that allow reserved characters keep them,
+// and all the other values are encoded like url.Values.Encode does.
+func queryValueEscaper(params []Parameter, noEncode bool) func(name, value string) string {
+	allowReserved := map[string]bool{}
+	for _, param := range params {
+		if param.AllowReserved {
+			allowReserved[param.Name] = true
+		}
+	}
+
+	return func(name, value string) string {
+		switch {
+		case noEncode:
+			return value
+		case allowReserved[name]:
+			return escapeKeepingReserved(value)
+		}
+		return url.QueryEscape(value)
+	}
 }
 
 // handleHeaderParameters extracts each header parameter from the input JSON and adds it to the request headers.
-func handleHeaderParameters(req *http.Request, params []Parameter, input string) {
+func handleHeaderParameters(req *http.Request, params []Parameter, input string, repeated []string) {
 	for _, param := range params {
-		res := gjson.Get(input, param.Name)
+		res := gjson.Get(input, argPath(param))
 		if res.Exists() {
-			if res.IsArray() {
+			if res.IsArray() && isRepeatedHeader(param.Name, repeated) {
+				for _, item := range res.Array() {
+					req.Header.Add(param.Name, valueString(item))
+				}
+			} else if res.IsArray() {
 				strs := make([]string, len(res.Array()))
 				for i, item := range res.Array() {
-					strs[i] = item.String()
+					strs[i] = valueString(item)
 				}
 				req.Header.Add(param.Name, strings.Join(strs, ","))
 			} else if res.IsObject() {
 				// Handle explosion
 				var strs []string
-				if param.Explode == nil || !*param.Explode { // default is to not explode
-					for k, v := range res.Map() {
-						strs = append(strs, k, v.String())
+				if !param.explode() {
+					for _, entry := range objectEntries(res) {
+						strs = append(strs, entry.key, valueString(entry.value))
 					}
 				} else {
-					for k, v := range res.Map() {
-						strs = append(strs, k+"="+v.String())
+					for _, entry := range objectEntries(res) {
+						strs = append(strs, entry.key+"="+valueString(entry.value))
 					}
 				}
 				req.Header.Add(param.Name, strings.Join(strs, ","))
 			} else { // basic type
-				req.Header.Add(param.Name, res.String())
+				req.Header.Add(param.Name, valueString(res))
 			}
 		}
 	}
 }
 
+// isRepeatedHeader returns whether the header is in the list of headers to repeat, or the list has "*".
+func isRepeatedHeader(name string, repeated []string) bool {
+	for _, r := range repeated {
+		if r == "*" || strings.EqualFold(r, name) {
+			return true
+		}
+	}
+	return false
+}
+
 // handleCookieParameters extracts each cookie parameter from the input JSON and adds it to the request cookies.
 func handleCookieParameters(req *http.Request, params []Parameter, input string) {
 	for _, param := range params {
-		res := gjson.Get(input, param.Name)
+		res := gjson.Get(input, argPath(param))
 		if res.Exists() {
 			if res.IsArray() {
 				strs := make([]string, len(res.Array()))
 				for i, item := range res.Array() {
-					strs[i] = item.String()
+					strs[i] = valueString(item)
+				}
+				if param.explode() {
+					for _, str := range strs {
+						req.AddCookie(&http.Cookie{
+							Name:  param.Name,
+							Value: str,
+						})
+					}
+				} else {
+					req.AddCookie(&http.Cookie{
+						Name:  param.Name,
+						Value: strings.Join(strs, ","),
+					})
 				}
-				req.AddCookie(&http.Cookie{
-					Name:  param.Name,
-					Value: strings.Join(strs, ","),
-				})
 			} else if res.IsObject() {
-				var strs []string
-				for k, v := range res.Map() {
-					strs = append(strs, k, v.String())
+				if param.explode() {
+					for _, entry := range objectEntries(res) {
+						req.AddCookie(&http.Cookie{
+							Name:  entry.key,
+							Value: valueString(entry.value),
+						})
+					}
+				} else {
+					var strs []string
+					for _, entry := range objectEntries(res) {
+						strs = append(strs, entry.key, valueString(entry.value))
+					}
+					req.AddCookie(&http.Cookie{
+						Name:  param.Name,
+						Value: strings.Join(strs, ","),
+					})
 				}
-				req.AddCookie(&http.Cookie{
-					Name:  param.Name,
-					Value: strings.Join(strs, ","),
-				})
 			} else { // basic type
 				req.AddCookie(&http.Cookie{
 					Name:  param.Name,
-					Value: res.String(),
+					Value: valueString(res),
 				})
 			}
 		}
 	}
 }
+
+// argPath returns the gjson path for a parameter's value in the input JSON.
+// Parameter names may contain characters that have special meaning in gjson paths (like "." in "page.size"),
+// so they are escaped to always refer to a top-level property.
+func argPath(param Parameter) string {
+	if param.ArgName == "" {
+		return gjson.Escape(param.Name)
+	}
+	return gjson.Escape(param.ArgName)
+}
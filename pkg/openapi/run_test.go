@@ -0,0 +1,389 @@
+package openapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func boolPtr(b bool) *bool {
+	return &b
+}
+
+const (
+	primitiveArg = `{"id": 5}`
+	arrayArg     = `{"id": [3, 4, 5]}`
+	objectArg    = `{"id": {"role": "admin", "firstName": "Alex"}}`
+)
+
+// The expected values follow the style examples of the OpenAPI specification.
+func TestHandlePathParameters(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   []Parameter
+		args     string
+		want     string
+	}{
+		{"simple primitive", "/users/{id}", []Parameter{{Name: "id", Style: "simple"}}, primitiveArg, "/users/5"},
+		{"simple array", "/users/{id}", []Parameter{{Name: "id", Style: "simple"}}, arrayArg, "/users/3,4,5"},
+		{"simple object", "/users/{id}", []Parameter{{Name: "id", Style: "simple"}}, objectArg, "/users/role,admin,firstName,Alex"},
+		{"simple explode primitive", "/users/{id}", []Parameter{{Name: "id", Style: "simple", Explode: boolPtr(true)}}, primitiveArg, "/users/5"},
+		{"simple explode array", "/users/{id}", []Parameter{{Name: "id", Style: "simple", Explode: boolPtr(true)}}, arrayArg, "/users/3,4,5"},
+		{"simple explode object", "/users/{id}", []Parameter{{Name: "id", Style: "simple", Explode: boolPtr(true)}}, objectArg, "/users/role=admin,firstName=Alex"},
+		{"default style is simple", "/users/{id}", []Parameter{{Name: "id"}}, objectArg, "/users/role,admin,firstName,Alex"},
+
+		{"label primitive", "/users/{id}", []Parameter{{Name: "id", Style: "label"}}, primitiveArg, "/users/.5"},
+		{"label array", "/users/{id}", []Parameter{{Name: "id", Style: "label"}}, arrayArg, "/users/.3,4,5"},
+		{"label object", "/users/{id}", []Parameter{{Name: "id", Style: "label"}}, objectArg, "/users/.role,admin,firstName,Alex"},
+		{"label explode primitive", "/users/{id}", []Parameter{{Name: "id", Style: "label", Explode: boolPtr(true)}}, primitiveArg, "/users/.5"},
+		{"label explode array", "/users/{id}", []Parameter{{Name: "id", Style: "label", Explode: boolPtr(true)}}, arrayArg, "/users/.3.4.5"},
+		{"label explode object", "/users/{id}", []Parameter{{Name: "id", Style: "label", Explode: boolPtr(true)}}, objectArg, "/users/.role=admin.firstName=Alex"},
+
+		{"matrix primitive", "/users/{id}", []Parameter{{Name: "id", Style: "matrix"}}, primitiveArg, "/users/;id=5"},
+		{"matrix array", "/users/{id}", []Parameter{{Name: "id", Style: "matrix"}}, arrayArg, "/users/;id=3,4,5"},
+		{"matrix object", "/users/{id}", []Parameter{{Name: "id", Style: "matrix"}}, objectArg, "/users/;id=role,admin,firstName,Alex"},
+		{"matrix explode primitive", "/users/{id}", []Parameter{{Name: "id", Style: "matrix", Explode: boolPtr(true)}}, primitiveArg, "/users/;id=5"},
+		{"matrix explode array", "/users/{id}", []Parameter{{Name: "id", Style: "matrix", Explode: boolPtr(true)}}, arrayArg, "/users/;id=3;id=4;id=5"},
+		{"matrix explode object", "/users/{id}", []Parameter{{Name: "id", Style: "matrix", Explode: boolPtr(true)}}, objectArg, "/users/;role=admin;firstName=Alex"},
+
+		{"simple empty string", "/users/{id}/x", []Parameter{{Name: "id", Style: "simple"}}, `{"id": ""}`, "/users//x"},
+		{"label empty string", "/users/{id}", []Parameter{{Name: "id", Style: "label"}}, `{"id": ""}`, "/users/."},
+		{"matrix empty string", "/users/{id}", []Parameter{{Name: "id", Style: "matrix"}}, `{"id": ""}`, "/users/;id"},
+		{"simple empty array", "/users/{id}", []Parameter{{Name: "id", Style: "simple"}}, `{"id": []}`, "/users/"},
+		{"label empty array", "/users/{id}", []Parameter{{Name: "id", Style: "label"}}, `{"id": []}`, "/users/"},
+		{"matrix empty array", "/users/{id}", []Parameter{{Name: "id", Style: "matrix", Explode: boolPtr(true)}}, `{"id": []}`, "/users/"},
+		{"label empty object", "/users/{id}", []Parameter{{Name: "id", Style: "label"}}, `{"id": {}}`, "/users/"},
+		{"matrix empty object", "/users/{id}", []Parameter{{Name: "id", Style: "matrix"}}, `{"id": {}}`, "/users/"},
+
+		{"explode modifier on simple object", "/users/{id*}", []Parameter{{Name: "id", Style: "simple"}}, objectArg, "/users/role=admin,firstName=Alex"},
+		{"explode modifier on label array", "/users/{id*}", []Parameter{{Name: "id", Style: "label", Explode: boolPtr(false)}}, arrayArg, "/users/.3.4.5"},
+		{"explode modifier on matrix array", "/users/{id*}", []Parameter{{Name: "id", Style: "matrix"}}, arrayArg, "/users/;id=3;id=4;id=5"},
+
+		{"space and slash are encoded", "/files/{name}", []Parameter{{Name: "name"}}, `{"name": "my dir/file.txt"}`, "/files/my%20dir%2Ffile.txt"},
+		{"reserved characters are encoded", "/files/{name}", []Parameter{{Name: "name"}}, `{"name": "a/b?c#d;e,f{id}"}`, "/files/a%2Fb%3Fc%23d%3Be%2Cf%7Bid%7D"},
+		{"reserved characters in array items", "/files/{name}", []Parameter{{Name: "name", Style: "label"}}, `{"name": ["a/b", "c?d"]}`, "/files/.a%2Fb,c%3Fd"},
+		{"reserved characters in object keys", "/files/{name}", []Parameter{{Name: "name", Explode: boolPtr(true)}}, `{"name": {"a/b": "c#d"}}`, "/files/a%2Fb=c%23d"},
+		// allowReserved only applies to query parameters, and keeping ? or # would change the URL.
+		{"allowReserved is ignored", "/files/{name}", []Parameter{{Name: "name", AllowReserved: true}}, `{"name": "a/b?c#d%"}`, "/files/a%2Fb%3Fc%23d%25"},
+
+		{"repeated placeholder", "/users/{id}/friends/{id}", []Parameter{{Name: "id"}}, primitiveArg, "/users/5/friends/5"},
+		{"repeated placeholder in one segment", "/compare/{id}...{id}", []Parameter{{Name: "id"}}, primitiveArg, "/compare/5...5"},
+		{"repeated label placeholder", "/users/{id}/{id}", []Parameter{{Name: "id", Style: "label"}}, arrayArg, "/users/.3,4,5/.3,4,5"},
+		{"repeated explode modifier", "/users/{id*}/{id*}", []Parameter{{Name: "id", Style: "matrix"}}, arrayArg, "/users/;id=3;id=4;id=5/;id=3;id=4;id=5"},
+
+		{"overlapping names", "/a/{id}/{id2}", []Parameter{{Name: "id"}, {Name: "id2"}}, `{"id": "x", "id2": "y"}`, "/a/x/y"},
+		{"overlapping names in reverse order", "/a/{id}/{id2}", []Parameter{{Name: "id2"}, {Name: "id"}}, `{"id": "x", "id2": "y"}`, "/a/x/y"},
+		{"overlapping names in one segment", "/a/{id}{id2}", []Parameter{{Name: "id"}, {Name: "id2"}}, `{"id": "x", "id2": "y"}`, "/a/xy"},
+		{"name that is a prefix of a longer one", "/a/{user}/{userId}", []Parameter{{Name: "user"}, {Name: "userId"}}, `{"user": "u", "userId": "7"}`, "/a/u/7"},
+		{"value containing a later placeholder", "/a/{id}/{id2}", []Parameter{{Name: "id"}, {Name: "id2"}}, `{"id": "{id2}", "id2": "y"}`, "/a/%7Bid2%7D/y"},
+		{"allowReserved value containing a later placeholder", "/a/{id}/{id2}", []Parameter{{Name: "id", AllowReserved: true}, {Name: "id2"}}, `{"id": "{id2}", "id2": "y"}`, "/a/%7Bid2%7D/y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := handlePathParameters(tt.template, tt.params, tt.args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// The expected values follow the style examples of the OpenAPI specification, percent-encoded.
+func TestHandleQueryParameters(t *testing.T) {
+	tests := []struct {
+		name  string
+		param Parameter
+		args  string
+		want  string
+	}{
+		{"form primitive", Parameter{Name: "id", In: "query", Style: "form", Explode: boolPtr(false)}, primitiveArg, "id=5"},
+		{"form array", Parameter{Name: "id", In: "query", Style: "form", Explode: boolPtr(false)}, arrayArg, "id=3%2C4%2C5"},
+		{"form object", Parameter{Name: "id", In: "query", Style: "form", Explode: boolPtr(false)}, objectArg, "id=role%2Cadmin%2CfirstName%2CAlex"},
+		{"form explode primitive", Parameter{Name: "id", In: "query", Style: "form", Explode: boolPtr(true)}, primitiveArg, "id=5"},
+		{"form explode array", Parameter{Name: "id", In: "query", Style: "form", Explode: boolPtr(true)}, arrayArg, "id=3&id=4&id=5"},
+		{"form explode object", Parameter{Name: "id", In: "query", Style: "form", Explode: boolPtr(true)}, objectArg, "firstName=Alex&role=admin"},
+		{"default style is form with explode", Parameter{Name: "id", In: "query"}, arrayArg, "id=3&id=4&id=5"},
+
+		{"deepObject object", Parameter{Name: "id", In: "query", Style: "deepObject", Explode: boolPtr(true)}, objectArg, "id%5BfirstName%5D=Alex&id%5Brole%5D=admin"},
+		{"deepObject primitive", Parameter{Name: "id", In: "query", Style: "deepObject", Explode: boolPtr(true)}, primitiveArg, "id=5"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := []Parameter{tt.param}
+			q, emptyParams := handleQueryParameters(url.Values{}, params, tt.args, BoolFormat{}, nil)
+			if got := encodeQuery(q, emptyParams, queryValueEscaper(params, false)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJoinURLPathKeepsDotSegments(t *testing.T) {
+	// An empty label value is a path segment that is just a dot, which cleaning the path would remove.
+	if got, want := joinURLPath("https://api.example.com/v1/", "/users/./posts"), "https://api.example.com/v1/users/./posts"; got != want {
+		t.Errorf("got %s, want %s", got, want)
+	}
+}
+
+func TestHandlePathParametersMissingValue(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   []Parameter
+		args     string
+		missing  string
+	}{
+		{"value not given", "/users/{id}", []Parameter{{Name: "id"}}, `{}`, "{id}"},
+		{"one of two values not given", "/users/{id}/posts/{postId}", []Parameter{{Name: "id"}, {Name: "postId"}}, `{"id": 5}`, "{postId}"},
+		{"placeholder without a parameter", "/users/{id}/posts/{postId}", []Parameter{{Name: "id"}}, `{"id": 5}`, "{postId}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, err := handlePathParameters(tt.template, tt.params, tt.args)
+			if err == nil {
+				t.Fatalf("got path %s, want an error", path)
+			}
+			if !strings.Contains(err.Error(), tt.missing) {
+				t.Errorf("got error %q, want it to name %s", err, tt.missing)
+			}
+		})
+	}
+}
+
+func TestRunSendsEncodedPathValues(t *testing.T) {
+	var requestURI string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestURI = r.RequestURI
+	}))
+	defer server.Close()
+
+	spec := filepath.Join(t.TempDir(), "openapi.yaml")
+	err := os.WriteFile(spec, []byte(`openapi: 3.0.3
+info: {title: Files, version: "1"}
+servers:
+  - url: `+server.URL+`
+paths:
+  /files/{name}:
+    get:
+      operationId: getFile
+      parameters:
+        - {name: name, in: path, required: true, schema: {type: string}}
+      responses:
+        "200": {description: OK}
+`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, found, err := Run("getFile", spec, `{"name": "my dir/file.txt"}`, RunOptions{}); err != nil || !found {
+		t.Fatalf("got found %v, error %v", found, err)
+	}
+	// The slash must stay encoded so that the value is one path segment.
+	if want := "/files/my%20dir%2Ffile.txt"; requestURI != want {
+		t.Errorf("got request URI %s, want %s", requestURI, want)
+	}
+}
+
+func TestParametersKeepNumberFormatting(t *testing.T) {
+	args := `{"id": 12345678901234567890, "price": 0.10000000000000000001, "sizes": [1e3, 2.50], "X-Version": 1.0}`
+
+	// The numbers are sent as they were written, without going through a float64.
+	path, err := handlePathParameters("/items/{id}", []Parameter{{Name: "id"}}, args)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "/items/12345678901234567890"; path != want {
+		t.Errorf("got path %s, want %s", path, want)
+	}
+
+	q, _ := handleQueryParameters(url.Values{}, []Parameter{{Name: "price"}, {Name: "sizes", Explode: boolPtr(false)}}, args, BoolFormat{}, nil)
+	if want := "price=0.10000000000000000001&sizes=1e3%2C2.50"; q.Encode() != want {
+		t.Errorf("got query %s, want %s", q.Encode(), want)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	handleHeaderParameters(req, []Parameter{{Name: "X-Version"}}, args, nil)
+	if value := req.Header.Get("X-Version"); value != "1.0" {
+		t.Errorf("got header X-Version %q, want 1.0", value)
+	}
+}
+
+func TestHandleQueryParametersBoolFormat(t *testing.T) {
+	tests := []struct {
+		name   string
+		args   string
+		format BoolFormat
+		want   string
+	}{
+		{"true by default", `{"active": true}`, BoolFormat{}, "active=true"},
+		{"false by default", `{"active": false}`, BoolFormat{}, "active=false"},
+		{"true as 1", `{"active": true}`, BoolFormat{True: "1", False: "0"}, "active=1"},
+		{"false as 0", `{"active": false}`, BoolFormat{True: "1", False: "0"}, "active=0"},
+		{"array items", `{"active": [true, false]}`, BoolFormat{True: "yes", False: "no"}, "active=yes&active=no"},
+		{"string is not a boolean", `{"active": "true"}`, BoolFormat{True: "1", False: "0"}, "active=true"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := []Parameter{{Name: "active", In: "query"}}
+			q, emptyParams := handleQueryParameters(url.Values{}, params, tt.args, tt.format, nil)
+			if got := encodeQuery(q, emptyParams, queryValueEscaper(params, false)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunSendsEmptyQueryValueAsBareName(t *testing.T) {
+	tests := []struct {
+		args string
+		want string
+	}{
+		{`{"debug": ""}`, "debug"},
+		{`{"debug": "", "q": "cats"}`, "q=cats&debug"},
+		// Only parameters with allowEmptyValue are sent without a value.
+		{`{"q": ""}`, "q="},
+	}
+	for _, tt := range tests {
+		rt := recordRequests(t)
+		if _, found, err := Run("search", "testdata/empty-query.yaml", tt.args, RunOptions{}); err != nil || !found {
+			t.Fatalf("got found %v, error %v", found, err)
+		}
+		if rawQuery := rt.requests[0].URL.RawQuery; rawQuery != tt.want {
+			t.Errorf("%s: got query %s, want %s", tt.args, rawQuery, tt.want)
+		}
+	}
+}
+
+func TestRunExtraHeadersReplaceBodyHeaders(t *testing.T) {
+	tests := []struct {
+		name        string
+		opts        RunOptions
+		contentType string
+	}{
+		{"declared media type", RunOptions{}, "application/json"},
+		{"extra header", RunOptions{Headers: http.Header{"Content-Type": {"application/vnd.api+json"}}}, "application/vnd.api+json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := recordRequests(t)
+			if _, _, err := Run("createPet", "testdata/extra-headers.yaml", `{"requestBodyContent": {"name": "Rex"}}`, tt.opts); err != nil {
+				t.Fatal(err)
+			}
+			if contentType := rt.requests[0].Header.Get("Content-Type"); contentType != tt.contentType {
+				t.Errorf("got Content-Type %q, want %q", contentType, tt.contentType)
+			}
+		})
+	}
+}
+
+// TestRunQueryArrayExplode checks that the explode setting of array query parameters is taken from the
+// document through GetSchema: unset means the form style default, repeated keys, and only an explicit
+// explode: false joins the items with commas.
+func TestRunQueryArrayExplode(t *testing.T) {
+	tests := []struct {
+		name, args, query string
+	}{
+		{"explode unset", `{"a": [1, 2]}`, "a=1&a=2"},
+		{"explode false", `{"b": [1, 2]}`, "b=1%2C2"},
+		{"explode true", `{"c": [1, 2]}`, "c=1&c=2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := recordRequests(t)
+			if _, _, err := Run("listItems", "testdata/explode.yaml", tt.args, RunOptions{}); err != nil {
+				t.Fatal(err)
+			}
+			if query := rt.requests[0].URL.RawQuery; query != tt.query {
+				t.Errorf("got query %q, want %q", query, tt.query)
+			}
+		})
+	}
+}
+
+func TestRunSendsNonObjectJSONBodies(t *testing.T) {
+	tests := []struct {
+		name, operationID, body string
+		valid                   bool
+	}{
+		{"array", "postIDs", `[1,2,3]`, true},
+		{"array with large integers", "postIDs", `[12345678901234567890,1]`, true},
+		{"array with a wrong item", "postIDs", `[1,"two"]`, false},
+		{"primitive", "postCount", `42`, true},
+		{"large primitive", "postCount", `98765432109876543210`, true},
+		{"wrong primitive", "postCount", `"42"`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := recordRequests(t)
+			_, _, err := Run(tt.operationID, "testdata/json-bodies.yaml", `{"requestBodyContent": `+tt.body+`}`, RunOptions{})
+			if !tt.valid {
+				var validationErr *ValidationError
+				if !errors.As(err, &validationErr) {
+					t.Fatalf("got error %v, want a validation error", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if body := strings.TrimSpace(rt.bodies[0]); body != tt.body {
+				t.Errorf("got body %s, want %s", body, tt.body)
+			}
+		})
+	}
+}
+
+func TestGetSchemaKeepsUnsetExplode(t *testing.T) {
+	_, info := getSchema(t, "listItems", "testdata/explode.yaml")
+
+	want := map[string]string{"a": "<nil>", "b": "false", "c": "true"}
+	for _, param := range info.QueryParams {
+		got := "<nil>"
+		if param.Explode != nil {
+			got = strconv.FormatBool(*param.Explode)
+		}
+		if got != want[param.Name] {
+			t.Errorf("got explode %s for %s, want %s", got, param.Name, want[param.Name])
+		}
+	}
+	if len(info.QueryParams) != len(want) {
+		t.Errorf("got %d query parameters, want %d", len(info.QueryParams), len(want))
+	}
+}
+
+func TestRunRepeatedHeaders(t *testing.T) {
+	tests := []struct {
+		name     string
+		repeated []string
+		values   []string
+	}{
+		{"comma-separated by default", nil, []string{"a,b"}},
+		{"repeated by name", []string{"X-Tags"}, []string{"a", "b"}},
+		{"repeated by name in another case", []string{"x-tags"}, []string{"a", "b"}},
+		{"repeated by *", []string{"*"}, []string{"a", "b"}},
+		{"other header repeated", []string{"X-Other"}, []string{"a,b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := recordRequests(t)
+			opts := RunOptions{RepeatedHeaders: tt.repeated}
+			if _, _, err := Run("listItems", "testdata/headers.yaml", `{"X-Tags": ["a", "b"]}`, opts); err != nil {
+				t.Fatal(err)
+			}
+			if values := rt.requests[0].Header.Values("X-Tags"); !slices.Equal(values, tt.values) {
+				t.Errorf("got X-Tags %q, want %q", values, tt.values)
+			}
+		})
+	}
+}
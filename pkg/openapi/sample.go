@@ -0,0 +1,152 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Sample synthesizes arguments for an operation from its schema, which can be edited and passed to Run.
+// lenientJSON allows comments and trailing commas in JSON documents.
+// Return values in order: arguments JSON (string), found (bool), error.
+func Sample(operationID, file string, lenientJSON bool) (string, bool, error) {
+	t, err := newLoader(lenientJSON).LoadFromFile(file)
+	if err != nil {
+		return "", false, &LoadError{File: file, Err: err}
+	}
+
+	arguments, _, found, err := operationArguments(t, operationID, SchemaOptions{})
+	if err != nil || !found {
+		return "", found, err
+	}
+
+	sample, err := json.MarshalIndent(sampler{request: true}.sampleValue(&openapi3.SchemaRef{Value: arguments}), "", "    ")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to marshal sample: %w", err)
+	}
+	return string(sample), true, nil
+}
+
+// maxSampleDepth limits how deep sampleValue goes, so that recursive schemas produce a finite sample.
+const maxSampleDepth = 8
+
+// sampler synthesizes values that match schemas.
+type sampler struct {
+	// request is set when sampling values to send in a request, which leaves out read-only properties.
+	// Otherwise, the values are sampled for a response, which leaves out write-only properties.
+	request bool
+}
+
+// sampleValue synthesizes a value that matches the schema. It prefers the schema's example, then its default,
+// then the first enum value, and otherwise builds a placeholder value of the schema's type.
+func (sm sampler) sampleValue(ref *openapi3.SchemaRef) any {
+	return sm.sampleValueDepth(ref, 0)
+}
+
+func (sm sampler) sampleValueDepth(ref *openapi3.SchemaRef, depth int) any {
+	if ref == nil || ref.Value == nil || depth > maxSampleDepth {
+		return nil
+	}
+	s := ref.Value
+
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.OneOf) > 0:
+		return sm.sampleValueDepth(s.OneOf[0], depth+1)
+	case len(s.AnyOf) > 0:
+		return sm.sampleValueDepth(s.AnyOf[0], depth+1)
+	case len(s.AllOf) > 0:
+		result := map[string]any{}
+		for _, sub := range s.AllOf {
+			if m, ok := sm.sampleValueDepth(sub, depth+1).(map[string]any); ok {
+				for k, v := range m {
+					result[k] = v
+				}
+			}
+		}
+		sm.addSampleProperties(result, s.Properties, depth)
+		return result
+	}
+
+	switch {
+	case s.Type.Is("object") || (s.Type == nil && len(s.Properties) > 0):
+		result := make(map[string]any, len(s.Properties))
+		sm.addSampleProperties(result, s.Properties, depth)
+		return result
+	case s.Type.Is("array") && s.Extensions["prefixItems"] != nil:
+		// A tuple needs a value for each of its positions.
+		items := tupleItemSchemas(s)
+		result := make([]any, 0, len(items))
+		for _, item := range items {
+			result = append(result, sm.sampleValueDepth(item, depth+1))
+		}
+		return result
+	case s.Type.Is("array"):
+		if item := sm.sampleValueDepth(s.Items, depth+1); item != nil {
+			return []any{item}
+		}
+		return []any{}
+	case s.Type.Is("string"):
+		return sampleString(s.Format)
+	case s.Type.Is("integer"):
+		if s.Min != nil {
+			return int64(*s.Min)
+		}
+		return 0
+	case s.Type.Is("number"):
+		if s.Min != nil {
+			return *s.Min
+		}
+		return 0.0
+	case s.Type.Is("boolean"):
+		return false
+	}
+	return nil
+}
+
+// addSampleProperties adds a sample value for each property to the object.
+// Properties that don't belong in the request or response, and properties without a sample, like those that are too deeply nested, are left out.
+func (sm sampler) addSampleProperties(object map[string]any, properties openapi3.Schemas, depth int) {
+	for name, property := range properties {
+		if property == nil || property.Value == nil ||
+			(sm.request && property.Value.ReadOnly) || (!sm.request && property.Value.WriteOnly) {
+			continue
+		}
+		if value := sm.sampleValueDepth(property, depth+1); value != nil {
+			object[name] = value
+		}
+	}
+}
+
+// sampleString returns a placeholder string for the format.
+func sampleString(format string) string {
+	switch format {
+	case "date-time":
+		return "2024-01-01T00:00:00Z"
+	case "date":
+		return "2024-01-01"
+	case "time":
+		return "00:00:00"
+	case "uuid":
+		return "00000000-0000-0000-0000-000000000000"
+	case "email":
+		return "user@example.com"
+	case "uri", "url":
+		return "https://example.com"
+	case "hostname":
+		return "example.com"
+	case "ipv4":
+		return "192.0.2.1"
+	case "ipv6":
+		return "2001:db8::1"
+	case "byte":
+		return "c3RyaW5n"
+	}
+	return "string"
+}
@@ -0,0 +1,86 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEnvCredentialsForCombinedSchemes(t *testing.T) {
+	var got *http.Request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		operationID string
+		env         map[string]string
+		noEnvAuth   bool
+		// headers and query are the credentials that must be sent, where an empty value means not sent.
+		headers map[string]string
+		query   map[string]string
+	}{
+		{
+			name:        "header and query key",
+			operationID: "headerAndQuery",
+			env:         map[string]string{"OPENAPI_API_KEY": "header-key", "OPENAPI_QUERY_KEY": "query-key"},
+			headers:     map[string]string{"X-Api-Key": "header-key"},
+			query:       map[string]string{"key": "query-key"},
+		},
+		{
+			name:        "bearer token and header key",
+			operationID: "bearerAndHeader",
+			env:         map[string]string{"OPENAPI_BEARER": "token", "OPENAPI_API_KEY": "header-key"},
+			headers:     map[string]string{"Authorization": "Bearer token", "X-Api-Key": "header-key"},
+		},
+		{
+			name:        "two header keys need their own variables",
+			operationID: "alternatives",
+			env:         map[string]string{"OPENAPI_API_KEY": "header-key", "OPENAPI_QUERY_KEY": "query-key"},
+			headers:     map[string]string{"X-Api-Key": "", "X-App-Id": ""},
+			query:       map[string]string{"key": "query-key"},
+		},
+		{
+			name:        "scheme variable",
+			operationID: "alternatives",
+			env:         map[string]string{"OPENAPI_API_KEY": "header-key", "OPENAPI_AUTH_APPID": "app", "OPENAPI_QUERY_KEY": "query-key"},
+			headers:     map[string]string{"X-Api-Key": "header-key", "X-App-Id": "app"},
+			query:       map[string]string{"key": ""},
+		},
+		{
+			name:        "no env auth",
+			operationID: "headerAndQuery",
+			env:         map[string]string{"OPENAPI_API_KEY": "header-key", "OPENAPI_QUERY_KEY": "query-key"},
+			noEnvAuth:   true,
+			headers:     map[string]string{"X-Api-Key": ""},
+			query:       map[string]string{"key": ""},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, name := range []string{"OPENAPI_BEARER", "OPENAPI_API_KEY", "OPENAPI_QUERY_KEY", "OPENAPI_AUTH_APPID"} {
+				t.Setenv(name, tt.env[name])
+			}
+
+			got = nil
+			if _, found, err := Run(tt.operationID, "testdata/security.yaml", "{}", RunOptions{BaseURL: server.URL, NoEnvAuth: tt.noEnvAuth}); err != nil || !found {
+				t.Fatalf("got found %v, error %v", found, err)
+			}
+			if got == nil {
+				t.Fatal("no request was received")
+			}
+			for name, want := range tt.headers {
+				if value := got.Header.Get(name); value != want {
+					t.Errorf("got header %s %q, want %q", name, value, want)
+				}
+			}
+			for name, want := range tt.query {
+				if value := got.URL.Query().Get(name); value != want {
+					t.Errorf("got query parameter %s %q, want %q", name, value, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// NewMockHandler returns an http.Handler that implements every operation in the file by responding
+// with the example (or a sample generated from the schema) of the operation's success response.
+// Requests are routed by matching their path against the spec's paths, with or without the base path
+// of the spec's first server. Paths with literal segments are matched before paths with placeholders there.
+// lenientJSON allows comments and trailing commas in JSON documents.
+func NewMockHandler(file string, lenientJSON bool) (http.Handler, error) {
+	t, err := newLoader(lenientJSON).LoadFromFile(file)
+	if err != nil {
+		return nil, &LoadError{File: file, Err: err}
+	}
+
+	var basePath string
+	if len(t.Servers) > 0 {
+		if u, err := url.Parse(t.Servers[0].URL); err == nil {
+			basePath = strings.TrimSuffix(u.Path, "/")
+		}
+	}
+
+	// More specific templates are matched first, so that /users/me isn't routed to /users/{id}.
+	templates := sortedTemplates(t.Paths)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths := []string{r.URL.Path}
+		if basePath != "" && strings.HasPrefix(r.URL.Path, basePath) {
+			paths = append(paths, strings.TrimPrefix(r.URL.Path, basePath))
+		}
+
+		pathFound := false
+		for _, requestPath := range paths {
+			for _, template := range templates {
+				if _, ok := matchPath(template, requestPath); !ok {
+					continue
+				}
+				pathFound = true
+				pathItem := t.Paths.Value(template)
+
+				if operation := pathItem.GetOperation(r.Method); operation != nil {
+					writeMockResponse(w, operation)
+					return
+				}
+			}
+		}
+
+		if pathFound {
+			writeMockError(w, http.StatusMethodNotAllowed, fmt.Sprintf("method %s not allowed for %s", r.Method, r.URL.Path))
+		} else {
+			writeMockError(w, http.StatusNotFound, fmt.Sprintf("no operation found for %s", r.URL.Path))
+		}
+	}), nil
+}
+
+// writeMockResponse writes the example response of the operation's preferred status code.
+func writeMockResponse(w http.ResponseWriter, operation *openapi3.Operation) {
+	status, response := mockResponse(operation)
+	if response == nil || len(response.Content) == 0 {
+		w.WriteHeader(status)
+		return
+	}
+
+	mediaType, content := preferredContent(response.Content)
+	w.Header().Set("Content-Type", mediaType)
+	w.WriteHeader(status)
+
+	value := mediaTypeExample(content)
+	if strings.Contains(mediaType, "json") {
+		_ = json.NewEncoder(w).Encode(value)
+	} else if value != nil {
+		_, _ = fmt.Fprint(w, value)
+	}
+}
+
+func writeMockError(w http.ResponseWriter, status int, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"error": message})
+}
+
+// mockResponse chooses the response to mock for an operation: 200 if it exists,
+// otherwise the lowest 2XX status, otherwise the default response, otherwise the lowest status.
+func mockResponse(operation *openapi3.Operation) (int, *openapi3.Response) {
+	if operation.Responses == nil {
+		return http.StatusOK, nil
+	}
+
+	responses := operation.Responses.Map()
+	codes := make([]string, 0, len(responses))
+	for code := range responses {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	pick := func(code string) (int, *openapi3.Response) {
+		status, err := strconv.Atoi(strings.ReplaceAll(strings.ToUpper(code), "X", "0"))
+		if err != nil || status == 0 {
+			status = http.StatusOK
+		}
+		return status, responses[code].Value
+	}
+
+	if _, ok := responses["200"]; ok {
+		return pick("200")
+	}
+	for _, code := range codes {
+		if strings.HasPrefix(code, "2") {
+			return pick(code)
+		}
+	}
+	if _, ok := responses["default"]; ok {
+		return pick("default")
+	}
+	if len(codes) > 0 {
+		return pick(codes[0])
+	}
+	return http.StatusOK, nil
+}
+
+// preferredContent returns the JSON media type of the content if there is one, otherwise the first media type by name.
+func preferredContent(content openapi3.Content) (string, *openapi3.MediaType) {
+	mediaTypes := make([]string, 0, len(content))
+	for mediaType := range content {
+		mediaTypes = append(mediaTypes, mediaType)
+	}
+	sort.Strings(mediaTypes)
+
+	for _, mediaType := range mediaTypes {
+		if strings.Contains(mediaType, "json") {
+			return mediaType, content[mediaType]
+		}
+	}
+	return mediaTypes[0], content[mediaTypes[0]]
+}
+
+// mediaTypeExample returns the example of the media type, the first of its named examples,
+// or a sample generated from its schema.
+func mediaTypeExample(content *openapi3.MediaType) any {
+	if content == nil {
+		return nil
+	}
+	if content.Example != nil {
+		return content.Example
+	}
+
+	names := make([]string, 0, len(content.Examples))
+	for name := range content.Examples {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if example := content.Examples[name]; example != nil && example.Value != nil && example.Value.Value != nil {
+			return example.Value.Value
+		}
+	}
+
+	return sampler{}.sampleValue(content.Schema)
+}
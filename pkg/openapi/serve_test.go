@@ -0,0 +1,59 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+func TestMockHandlerRoutesLiteralPathsFirst(t *testing.T) {
+	handler, err := NewMockHandler("testdata/overlapping-paths.yaml", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		method, path string
+		status       int
+		body         string
+	}{
+		{http.MethodGet, "/users/me", http.StatusOK, `"me"`},
+		{http.MethodGet, "/v1/users/me", http.StatusOK, `"me"`},
+		{http.MethodGet, "/users/42", http.StatusOK, `"someone"`},
+		// /users/me has no DELETE, so it falls through to /users/{id}.
+		{http.MethodDelete, "/users/me", http.StatusNoContent, ""},
+		{http.MethodPut, "/users/42", http.StatusMethodNotAllowed, ""},
+		{http.MethodGet, "/nowhere", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			// Paths used to be matched in map order, so repeat the request to catch a random route.
+			for i := 0; i < 50; i++ {
+				w := httptest.NewRecorder()
+				handler.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+				if w.Code != tt.status {
+					t.Fatalf("got status %d, want %d", w.Code, tt.status)
+				}
+				if !strings.Contains(w.Body.String(), tt.body) {
+					t.Fatalf("got body %q, want it to contain %q", w.Body.String(), tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestSortedTemplates(t *testing.T) {
+	handlerSpec, err := openapi3.NewLoader().LoadFromFile("testdata/overlapping-paths.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got := sortedTemplates(handlerSpec.Paths)
+	want := []string{"/users/me", "/users/me/posts/{postId}", "/users/{id}", "/users/{id}/posts/{postId}"}
+	if strings.Join(got, " ") != strings.Join(want, " ") {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
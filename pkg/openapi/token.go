@@ -0,0 +1,56 @@
+package openapi
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+// TokenCommand gets a bearer token from an external credential helper, like gcloud auth print-access-token.
+// The command is run with sh -c the first time a token is needed, and what it writes to stdout is the token.
+// The token, or the error, is kept for the lifetime of the TokenCommand, so that the other requests of the
+// same command, like pages, don't run the command again.
+type TokenCommand struct {
+	Command string
+
+	once  sync.Once
+	token string
+	err   error
+}
+
+func NewTokenCommand(command string) *TokenCommand {
+	return &TokenCommand{Command: command}
+}
+
+// Token returns the token that the command printed, running it if it hasn't run yet.
+func (c *TokenCommand) Token() (string, error) {
+	c.once.Do(func() {
+		c.token, c.err = c.run()
+	})
+	return c.token, c.err
+}
+
+func (c *TokenCommand) run() (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", c.Command)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("token command failed: %w: %s", err, msg)
+		}
+		return "", fmt.Errorf("token command failed: %w", err)
+	}
+
+	token := strings.TrimSpace(stdout.String())
+	switch {
+	case token == "":
+		return "", fmt.Errorf("token command printed no token")
+	case strings.ContainsAny(token, "\r\n"):
+		// The output isn't included, since it may contain secrets.
+		return "", fmt.Errorf("token command printed more than one line")
+	}
+	return token, nil
+}
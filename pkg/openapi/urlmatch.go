@@ -0,0 +1,169 @@
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/tidwall/gjson"
+)
+
+// URLMatch is an operation found by matching a request URL against the paths in a document.
+type URLMatch struct {
+	OperationID string
+	// Args holds the values of the operation's path and query parameters from the URL, keyed by argument name.
+	Args map[string]any
+	// ExtraQuery holds the query parameters from the URL that the operation doesn't declare.
+	ExtraQuery url.Values
+}
+
+// MatchURL finds the operation in the file that a request URL was made for, by matching the URL's path
+// against each path template, with the base path of any of the document's servers removed.
+// Only the most specific templates are considered, so /users/me matches its own operation rather than /users/{id}.
+// If method is empty, the URL must match a single operation. lenientJSON allows comments and trailing commas in JSON documents.
+func MatchURL(file, method, rawURL string, lenientJSON bool) (URLMatch, bool, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return URLMatch{}, false, fmt.Errorf("failed to parse URL %s: %w", rawURL, err)
+	}
+
+	t, err := newLoader(lenientJSON).LoadFromFile(file)
+	if err != nil {
+		return URLMatch{}, false, &LoadError{File: file, Err: err}
+	}
+
+	var (
+		basePaths  = serverBasePaths(t)
+		candidates []string
+		pathValues = map[string]map[string]string{}
+		templates  = map[string]string{}
+	)
+	for _, template := range sortedTemplates(t.Paths) {
+		pathItem := t.Paths.Value(template)
+		for _, basePath := range basePaths {
+			if !strings.HasPrefix(u.Path, basePath) {
+				continue
+			}
+			values, ok := matchPath(template, strings.TrimPrefix(u.Path, basePath))
+			if !ok {
+				continue
+			}
+
+			for m, operation := range pathItem.Operations() {
+				if operation.OperationID == "" || (method != "" && !strings.EqualFold(m, method)) {
+					continue
+				}
+				if _, ok := pathValues[operation.OperationID]; !ok {
+					candidates = append(candidates, operation.OperationID)
+					pathValues[operation.OperationID] = values
+					templates[operation.OperationID] = template
+				}
+			}
+			break
+		}
+	}
+
+	// The templates were matched most specific first, so the first candidate's template is the most specific.
+	if len(candidates) > 0 {
+		best := templates[candidates[0]]
+		candidates = slices.DeleteFunc(candidates, func(operationID string) bool {
+			return compareTemplates(templates[operationID], best) != 0
+		})
+	}
+
+	switch {
+	case len(candidates) == 0:
+		return URLMatch{}, false, nil
+	case len(candidates) > 1 && method == "":
+		slices.Sort(candidates)
+		return URLMatch{}, false, fmt.Errorf("URL %s matches multiple operations, specify the method: %s", rawURL, strings.Join(candidates, ", "))
+	case len(candidates) > 1:
+		slices.Sort(candidates)
+		return URLMatch{}, false, fmt.Errorf("%s %s matches multiple equally specific paths: %s", strings.ToUpper(method), rawURL, strings.Join(candidates, ", "))
+	}
+
+	operationID := candidates[0]
+	schemaJSON, info, _, err := GetSchema(operationID, file, SchemaOptions{LenientJSON: lenientJSON})
+	if err != nil {
+		return URLMatch{}, false, err
+	}
+	properties := gjson.Get(schemaJSON, "properties")
+
+	match := URLMatch{
+		OperationID: operationID,
+		Args:        map[string]any{},
+		ExtraQuery:  url.Values{},
+	}
+	for _, param := range info.PathParams {
+		if value, ok := pathValues[operationID][param.Name]; ok {
+			match.Args[param.ArgName] = typedArgValue(properties.Get(gjson.Escape(param.ArgName)), []string{value})
+		}
+	}
+
+	query := u.Query()
+	for _, param := range info.QueryParams {
+		if values, ok := query[param.Name]; ok {
+			match.Args[param.ArgName] = typedArgValue(properties.Get(gjson.Escape(param.ArgName)), values)
+			delete(query, param.Name)
+		}
+	}
+	for name, values := range query {
+		match.ExtraQuery[name] = values
+	}
+	return match, true, nil
+}
+
+// serverBasePaths returns the paths of all the servers in the document, longest first,
+// followed by an empty path for documents whose paths are relative to the host.
+func serverBasePaths(t *openapi3.T) []string {
+	servers := slices.Clone(t.Servers)
+	for _, pathItem := range t.Paths.Map() {
+		servers = append(servers, pathItem.Servers...)
+		for _, operation := range pathItem.Operations() {
+			if operation.Servers != nil {
+				servers = append(servers, *operation.Servers...)
+			}
+		}
+	}
+
+	var paths []string
+	for _, server := range servers {
+		s, err := parseServer(server, nil)
+		if err != nil {
+			continue
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			continue
+		}
+		if p := strings.TrimSuffix(u.Path, "/"); p != "" && !slices.Contains(paths, p) {
+			paths = append(paths, p)
+		}
+	}
+	slices.SortFunc(paths, func(a, b string) int { return len(b) - len(a) })
+	return append(paths, "")
+}
+
+// typedArgValue converts the string values of a parameter from a URL to the type of its schema.
+// Values that don't parse as the schema's type are kept as strings, so that validation reports them.
+func typedArgValue(schema gjson.Result, values []string) any {
+	if schema.Get("type").String() == "array" {
+		items := make([]any, 0, len(values))
+		for _, value := range values {
+			items = append(items, typedArgValue(schema.Get("items"), []string{value}))
+		}
+		return items
+	}
+
+	value := values[0]
+	switch schema.Get("type").String() {
+	case "integer", "number", "boolean":
+		if json.Valid([]byte(value)) {
+			return json.RawMessage(value)
+		}
+	}
+	return value
+}
@@ -0,0 +1,105 @@
+package openapi
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestMatchURL(t *testing.T) {
+	tests := []struct {
+		name, file, method, url string
+		operationID             string
+		args                    string
+		err                     string
+	}{
+		{
+			name:        "literal path wins with method",
+			file:        "testdata/overlapping-paths.yaml",
+			method:      "GET",
+			url:         "https://api.example.com/v1/users/me",
+			operationID: "getCurrentUser",
+			args:        "map[]",
+		},
+		{
+			name:        "literal path wins without method",
+			file:        "testdata/overlapping-paths.yaml",
+			url:         "https://api.example.com/v1/users/me",
+			operationID: "getCurrentUser",
+			args:        "map[]",
+		},
+		{
+			name:        "templated path",
+			file:        "testdata/overlapping-paths.yaml",
+			method:      "GET",
+			url:         "https://api.example.com/v1/users/42",
+			operationID: "getUser",
+			args:        "map[id:42]",
+		},
+		{
+			name:        "method only on templated path",
+			file:        "testdata/overlapping-paths.yaml",
+			method:      "DELETE",
+			url:         "https://api.example.com/v1/users/me",
+			operationID: "deleteUser",
+			args:        "map[id:me]",
+		},
+		{
+			name:        "literal segment before placeholder",
+			file:        "testdata/overlapping-paths.yaml",
+			method:      "GET",
+			url:         "https://api.example.com/v1/users/me/posts/7",
+			operationID: "getCurrentUserPost",
+			args:        "map[postId:7]",
+		},
+		{
+			name:   "equally specific paths with method",
+			file:   "testdata/ambiguous-paths.yaml",
+			method: "GET",
+			url:    "https://api.example.com/files/report",
+			err:    "GET https://api.example.com/files/report matches multiple equally specific paths: getFile, getFileByName",
+		},
+		{
+			name: "equally specific paths without method",
+			file: "testdata/ambiguous-paths.yaml",
+			url:  "https://api.example.com/files/report",
+			err:  "specify the method",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Paths used to be matched in map order, so repeat the match to catch a random result.
+			for i := 0; i < 20; i++ {
+				match, found, err := MatchURL(tt.file, tt.method, tt.url, false)
+				if tt.err != "" {
+					if err == nil || !strings.Contains(err.Error(), tt.err) {
+						t.Fatalf("got error %v, want it to contain %q", err, tt.err)
+					}
+					continue
+				}
+				if err != nil || !found {
+					t.Fatalf("got found %v, error %v", found, err)
+				}
+				if match.OperationID != tt.operationID {
+					t.Fatalf("got operation %s, want %s", match.OperationID, tt.operationID)
+				}
+				if args := fmt.Sprint(match.Args); args != tt.args {
+					t.Fatalf("got args %s, want %s", args, tt.args)
+				}
+			}
+		})
+	}
+}
+
+func TestMatchURLQueryWithoutValue(t *testing.T) {
+	match, found, err := MatchURL("testdata/empty-query.yaml", "GET", "https://api.example.com/search?debug&q=cats&verbose", false)
+	if err != nil || !found {
+		t.Fatalf("got found %v, error %v", found, err)
+	}
+	if got := fmt.Sprint(match.Args); got != "map[debug: q:cats]" {
+		t.Errorf("got args %s, want debug with an empty value", got)
+	}
+	if values := match.ExtraQuery["verbose"]; len(values) != 1 || values[0] != "" {
+		t.Errorf("got extra query %v, want verbose with an empty value", match.ExtraQuery)
+	}
+}
@@ -0,0 +1,93 @@
+package openapi
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// DocumentReport lists the problems found in an OpenAPI document. Errors make the document invalid,
+// while warnings are problems that still let it be loaded but keep some of it from being used, like
+// operations that can't be run.
+type DocumentReport struct {
+	File     string   `json:"file"`
+	Errors   []string `json:"errors"`
+	Warnings []string `json:"warnings"`
+}
+
+// ValidateDocument validates an OpenAPI document against the specification and checks it for problems that
+// the specification allows: operations without an ID, operation IDs that are used more than once, and servers
+// that requests can't be sent to. A document that can't be loaded is reported as an error.
+func ValidateDocument(file string, lenientJSON bool) DocumentReport {
+	report := DocumentReport{File: file, Errors: []string{}, Warnings: []string{}}
+
+	t, err := newLoader(lenientJSON).LoadFromFile(file)
+	if err != nil {
+		report.Errors = append(report.Errors, (&LoadError{File: file, Err: err}).Error())
+		return report
+	}
+
+	// Webhooks are supported, though the loader only keeps them as an extension.
+	if err := t.Validate(context.Background(), openapi3.AllowExtraSiblingFields("webhooks")); err != nil {
+		report.Errors = append(report.Errors, err.Error())
+	}
+
+	warn := func(format string, args ...any) {
+		report.Warnings = append(report.Warnings, fmt.Sprintf(format, args...))
+	}
+
+	paths := map[string][]string{}
+	for _, path := range sortedKeys(t.Paths.Map()) {
+		pathItem := t.Paths.Value(path)
+		for _, server := range pathItem.Servers {
+			checkServer(server, "path "+path, warn)
+		}
+		for _, method := range sortedKeys(pathItem.Operations()) {
+			operation := pathItem.GetOperation(method)
+			if operation.OperationID == "" {
+				warn("operation %s %s has no operationId, so it can't be run", method, path)
+				continue
+			}
+			paths[operation.OperationID] = append(paths[operation.OperationID], method+" "+path)
+
+			if operation.Servers != nil {
+				for _, server := range *operation.Servers {
+					checkServer(server, "operation "+operation.OperationID, warn)
+				}
+			}
+		}
+	}
+	for _, operationID := range sortedKeys(paths) {
+		if len(paths[operationID]) > 1 {
+			warn("operation ID %s is used by %d operations (%s), so only one of them can be run", operationID, len(paths[operationID]), strings.Join(paths[operationID], ", "))
+		}
+	}
+
+	if len(t.Servers) == 0 {
+		warn("the document has no servers, so requests need a base URL")
+	}
+	for _, server := range t.Servers {
+		checkServer(server, "the document", warn)
+	}
+
+	return report
+}
+
+// checkServer warns about a server of the owner that requests can't be sent to: one whose variables
+// can't be filled in from their defaults, or whose URL isn't absolute.
+func checkServer(server *openapi3.Server, owner string, warn func(format string, args ...any)) {
+	if server == nil {
+		return
+	}
+	serverURL, err := parseServer(server, nil)
+	if err != nil {
+		warn("server %s of %s can't be used: %v", server.URL, owner, err)
+		return
+	}
+	if u, err := url.Parse(serverURL); err != nil || u.Scheme == "" || u.Host == "" {
+		warn("server %s of %s is not an absolute URL, so requests can't be sent to it", server.URL, owner)
+	}
+}
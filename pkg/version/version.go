@@ -0,0 +1,4 @@
+package version
+
+// Version is the version of openapi-cli. It is set at build time using -ldflags.
+var Version = "dev"